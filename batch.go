@@ -0,0 +1,64 @@
+package pipe
+
+import (
+	"time"
+)
+
+// Batch groups the items of in into batches of at most size items, sent once full or once linger is elapsed since their first item.
+// A size or a linger which is not positive doesn't bound the batches. The last batch is sent when in is closed, then the output is closed.
+//
+// Batch doesn't need any pool, so it works at the top level as well as in a Split or a Merge, to group childs before bulk operations.
+func Batch[T any](in <-chan T, size int, linger time.Duration) <-chan []T {
+	out := make(chan []T)
+	go func() {
+		defer close(out)
+		var batch []T
+		var timer *time.Timer
+		var expired <-chan time.Time // nil while the batch is empty or without linger
+		flush := func() {
+			if timer != nil {
+				timer.Stop()
+				timer, expired = nil, nil
+			}
+			if len(batch) > 0 {
+				out <- batch
+				batch = nil
+			}
+		}
+		for {
+			select {
+			case item, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+				batch = append(batch, item)
+				if len(batch) == 1 && linger > 0 {
+					timer = time.NewTimer(linger)
+					expired = timer.C
+				}
+				if size > 0 && len(batch) >= size {
+					flush()
+				}
+			case <-expired:
+				timer, expired = nil, nil
+				flush()
+			}
+		}
+	}()
+	return out
+}
+
+// Unbatch sends the items of the batches of in one by one, in order, then closes the output once in is closed.
+func Unbatch[T any](in <-chan []T) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for batch := range in {
+			for _, item := range batch {
+				out <- item
+			}
+		}
+	}()
+	return out
+}
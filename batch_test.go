@@ -0,0 +1,80 @@
+package pipe_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+func TestBatch(t *testing.T) {
+	t.Run("size", func(t *testing.T) {
+		// Arrange
+		in := lo.SliceToChannel(0, lo.Range(7))
+
+		// Act
+		out := pipe.Batch(in, 3, 0)
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), [][]int{{0, 1, 2}, {3, 4, 5}, {6}})
+	})
+
+	t.Run("linger", func(t *testing.T) {
+		// Arrange
+		in := make(chan int)
+		go func() {
+			defer close(in)
+			in <- 1
+			in <- 2
+			time.Sleep(50 * time.Millisecond)
+			in <- 3
+		}()
+
+		// Act
+		out := pipe.Batch(in, 10, 10*time.Millisecond)
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), [][]int{{1, 2}, {3}})
+	})
+
+	t.Run("unbatch", func(t *testing.T) {
+		// Arrange
+		in := lo.SliceToChannel(0, [][]int{{1, 2}, {}, {3}})
+
+		// Act
+		out := pipe.Unbatch(in)
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), []int{1, 2, 3})
+	})
+
+	t.Run("merge_batches", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1, 2)
+		type bulk struct {
+			size   int
+			writes [][]int
+		}
+		dispatcher, _ := pipe.NewDispatch(func(parent bulk, in chan<- int) {
+			for i := 0; i < parent.size; i++ {
+				in <- i
+			}
+		}, func(parent bulk, out <-chan int) bulk {
+			for batch := range pipe.Batch(out, 2, time.Second) {
+				parent.writes = append(parent.writes, batch)
+			}
+			return parent
+		})
+
+		// Act
+		out := pipe.Pipe(pool, lo.SliceToChannel(0, []bulk{{size: 5}}), pipe.Wrap(identity[int], dispatcher))
+
+		// Assert
+		results := lo.ChannelToSlice(out)
+		td.Require(t).Len(results, 1)
+		td.Cmp(t, lo.Map(results[0].writes, func(batch []int, _ int) int { return len(batch) }), []int{2, 2, 1})
+		td.Cmp(t, lo.Flatten(results[0].writes), td.Bag(0, 1, 2, 3, 4))
+	})
+}
@@ -64,7 +64,9 @@ func Profile(childRatio int, poolSizes ...int) {
 
 		// Run pipe
 		start := time.Now()
-		pipe.Run(pool, in, process)
+		if err := pipe.Run(pool, in, process); err != nil {
+			fmt.Println(err)
+		}
 		fmt.Printf("(par: %s)\n", time.Since(start))
 	}()
 
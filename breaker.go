@@ -0,0 +1,185 @@
+package pipe
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is the error of an item rejected by an open CircuitBreaker without fallback.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// BreakerState is the state of a CircuitBreaker.
+type BreakerState int
+
+const (
+	// BreakerClosed lets the items through, counting the consecutive failures.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects the items, until its cooldown is over.
+	BreakerOpen
+	// BreakerHalfOpen lets a single probe item through: its success closes the circuit, its failure opens it again.
+	BreakerHalfOpen
+)
+
+// String returns the name of the state.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerEvent describes the state change of a CircuitBreaker.
+type BreakerEvent struct {
+	Name string
+	From BreakerState
+	To   BreakerState
+}
+
+// BreakerObserver may be implemented by an Observer, to be notified of the state changes of the circuit breakers used on the pools.
+// It is notified once the breaker is unlocked, so it may call the breaker.
+type BreakerObserver interface {
+	BreakerChange(BreakerEvent)
+}
+
+// CircuitBreaker stops the calls to a failing dependency: it opens after threshold consecutive failures, rejects the items during cooldown,
+// then lets a probe item through. It can be shared by several Breaker processes, in several pipelines.
+type CircuitBreaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+
+	mutex    sync.Mutex
+	state    BreakerState
+	failures int // consecutive failures
+	openedAt time.Time
+	probing  bool // a probe item is running in half-open state
+}
+
+// NewCircuitBreaker creates a closed CircuitBreaker. Its name identifies it in the metrics and the logs.
+func NewCircuitBreaker(name string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{name: name, threshold: max(threshold, 1), cooldown: cooldown}
+}
+
+// State returns the current state of the breaker.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.state
+}
+
+// allow tells if an item may go through, moving an open breaker whose cooldown is over to half-open.
+// probe tells if the item is the probe of a half-open breaker.
+func (b *CircuitBreaker) allow(pool *Pools) (ok, probe bool) {
+	var changes []BreakerEvent
+	defer func() { b.notify(pool, changes) }() // once unlocked
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cooldown {
+		changes = append(changes, b.change(BreakerHalfOpen))
+	}
+	switch b.state {
+	case BreakerClosed:
+		return true, false
+	case BreakerHalfOpen:
+		if b.probing {
+			return false, false
+		}
+		b.probing = true
+		return true, true
+	}
+	return false, false
+}
+
+// record updates the breaker with the outcome of an item it let through. A deferred item has no outcome yet, except its failed attempt.
+// Only the outcome of the probe moves the breaker out of half-open: the items let through before are late.
+func (b *CircuitBreaker) record(pool *Pools, probe bool, err error) {
+	var changes []BreakerEvent
+	defer func() { b.notify(pool, changes) }() // once unlocked
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if probe {
+		b.probing = false
+	}
+	var later *Deferred
+	if errors.As(err, &later) {
+		if later.Err == nil {
+			return
+		}
+		err = later.Err
+	}
+	if err == nil {
+		b.failures = 0
+		if probe {
+			changes = append(changes, b.change(BreakerClosed))
+		}
+		return
+	}
+	b.failures++
+	if probe || (b.state == BreakerClosed && b.failures >= b.threshold) {
+		b.openedAt = time.Now()
+		changes = append(changes, b.change(BreakerOpen))
+	}
+}
+
+// change moves the breaker to a new state, and returns the event to notify once the mutex is unlocked.
+func (b *CircuitBreaker) change(state BreakerState) BreakerEvent {
+	ev := BreakerEvent{Name: b.name, From: b.state, To: state}
+	b.state = state
+	return ev
+}
+
+// notify gives the state changes of the breaker to the observers of the pools and logs them. The mutex must not be held,
+// so the observers may call the breaker.
+func (b *CircuitBreaker) notify(pool *Pools, changes []BreakerEvent) {
+	if pool == nil || pool.config == nil {
+		return
+	}
+	for _, ev := range changes {
+		for _, observer := range pool.config.observers {
+			if breakerObserver, ok := observer.(BreakerObserver); ok {
+				breakerObserver.BreakerChange(ev)
+			}
+		}
+		pool.config.log(pool.Context(), slog.LevelWarn, "circuit breaker state changed",
+			slog.String("breaker", b.name), slog.String("from", ev.From.String()), slog.String("to", ev.To.String()))
+	}
+}
+
+// Breaker decorates an ErrProcess with a CircuitBreaker. While the circuit is open, the items are handed to fallback, or fail with ErrCircuitOpen if it is nil.
+//
+// Breaker should decorate the process itself, inside a Retry or a RateLimit, so each attempt is an outcome of the breaker.
+// Otherwise, an item deferred by proc goes through the breaker again once resumed.
+func Breaker[T any](breaker *CircuitBreaker, proc, fallback ErrProcess[T]) ErrProcess[T] {
+	return func(pool *Pools, t T) (T, error) {
+		ok, probe := breaker.allow(pool)
+		if !ok {
+			if fallback != nil {
+				return fallback(pool, t)
+			}
+			return t, ErrCircuitOpen
+		}
+		defer func() {
+			if v := recover(); v != nil {
+				breaker.record(pool, probe, fmt.Errorf("panic: %v", v)) // a panic is a failure
+				panic(v)
+			}
+		}()
+		result, err := proc(pool, t)
+		breaker.record(pool, probe, err)
+		var later *Deferred
+		if errors.As(err, &later) {
+			return result, deferItem(later.Delay, later.Err, func(pool *Pools) (T, error) {
+				return Breaker(breaker, func(pool *Pools, _ T) (T, error) { return resumeAs[T](later, pool) }, fallback)(pool, t)
+			})
+		}
+		return result, err
+	}
+}
@@ -0,0 +1,185 @@
+package pipe_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+// breakerRecorder is an Observer which records the state changes of the breakers.
+type breakerRecorder struct {
+	recorder
+	mutex   sync.Mutex
+	changes []pipe.BreakerEvent
+}
+
+func (r *breakerRecorder) BreakerChange(ev pipe.BreakerEvent) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.changes = append(r.changes, ev)
+}
+
+// stateReader is an Observer which reads the state of the breaker it is notified of.
+type stateReader struct {
+	recorder
+	breaker *pipe.CircuitBreaker
+	states  []pipe.BreakerState
+}
+
+func (r *stateReader) BreakerChange(pipe.BreakerEvent) {
+	r.states = append(r.states, r.breaker.State())
+}
+
+func TestBreaker(t *testing.T) {
+	// down fails every item, counting the calls
+	type down struct {
+		mutex sync.Mutex
+		calls int
+	}
+	process := func(d *down) pipe.ErrProcess[int] {
+		return func(_ *pipe.Pools, i int) (int, error) {
+			d.mutex.Lock()
+			defer d.mutex.Unlock()
+			d.calls++
+			return i, errOdd
+		}
+	}
+
+	t.Run("open", func(t *testing.T) {
+		// Arrange
+		var rec breakerRecorder
+		pool := InitPoolWithConfig(t, []int{1}, pipe.WithObserver(&rec))
+		breaker := pipe.NewCircuitBreaker("api", 2, time.Hour)
+		var d down
+
+		// Act
+		err := pipe.RunErr(pool, lo.SliceToChannel(0, lo.Range(5)), pipe.Breaker(breaker, process(&d), nil), nil)
+
+		// Assert
+		td.CmpErrorIs(t, err, errOdd)
+		td.CmpErrorIs(t, err, pipe.ErrCircuitOpen)
+		td.Cmp(t, d.calls, 2, "The dependency is not called anymore once the circuit is open")
+		td.Cmp(t, breaker.State(), pipe.BreakerOpen)
+		td.Cmp(t, rec.changes, []pipe.BreakerEvent{{Name: "api", From: pipe.BreakerClosed, To: pipe.BreakerOpen}})
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1)
+		breaker := pipe.NewCircuitBreaker("api", 1, time.Hour)
+		var d down
+		fallback := func(_ *pipe.Pools, i int) (int, error) { return -i, nil }
+
+		// Act
+		out, errc := pipe.PipeErr(pool, lo.SliceToChannel(0, []int{1, 2, 3}), pipe.Breaker(breaker, process(&d), fallback), nil)
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), []int{-2, -3})
+		td.CmpErrorIs(t, <-errc, errOdd)
+	})
+
+	t.Run("half_open", func(t *testing.T) {
+		// Arrange
+		var rec breakerRecorder
+		pool := InitPoolWithConfig(t, []int{1}, pipe.WithObserver(&rec))
+		breaker := pipe.NewCircuitBreaker("api", 1, 20*time.Millisecond)
+		failFirst := func(_ *pipe.Pools, i int) (int, error) {
+			if i == 0 {
+				return i, errOdd
+			}
+			return i, nil
+		}
+		limited := pipe.Breaker(breaker, failFirst, nil)
+		_ = pipe.RunErr(pool, lo.SliceToChannel(0, []int{0}), limited, nil)
+		time.Sleep(25 * time.Millisecond)
+
+		// Act
+		err := pipe.RunErr(pool, lo.SliceToChannel(0, []int{0, 1, 2}), limited, nil)
+
+		// Assert
+		td.CmpErrorIs(t, err, errOdd, "The probe fails")
+		td.CmpErrorIs(t, err, pipe.ErrCircuitOpen)
+		time.Sleep(25 * time.Millisecond)
+		td.CmpNoError(t, pipe.RunErr(pool, lo.SliceToChannel(0, []int{1, 2}), limited, nil), "The probe succeeds")
+		td.Cmp(t, breaker.State(), pipe.BreakerClosed)
+		td.Cmp(t, lo.Map(rec.changes, func(ev pipe.BreakerEvent, _ int) pipe.BreakerState { return ev.To }), []pipe.BreakerState{
+			pipe.BreakerOpen, pipe.BreakerHalfOpen, pipe.BreakerOpen, pipe.BreakerHalfOpen, pipe.BreakerClosed,
+		})
+	})
+
+	t.Run("late_outcome", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1)
+		breaker := pipe.NewCircuitBreaker("api", 1, 20*time.Millisecond)
+		release := map[int]chan struct{}{1: make(chan struct{}), 2: make(chan struct{})}
+		blocking := pipe.Breaker(breaker, func(_ *pipe.Pools, i int) (int, error) {
+			if i == 0 {
+				return i, errOdd
+			}
+			if wait, ok := release[i]; ok {
+				<-wait
+			}
+			return i, nil
+		}, nil)
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() { // admitted while closed, over once half-open
+			defer wg.Done()
+			_, _ = blocking(pool, 1)
+		}()
+		time.Sleep(5 * time.Millisecond)
+		_, err := blocking(pool, 0)
+		td.Require(t).CmpErrorIs(err, errOdd)
+		time.Sleep(25 * time.Millisecond)
+		go func() { // the probe
+			defer wg.Done()
+			_, _ = blocking(pool, 2)
+		}()
+		time.Sleep(5 * time.Millisecond)
+
+		// Act
+		close(release[1])
+		time.Sleep(5 * time.Millisecond)
+
+		// Assert
+		td.Cmp(t, breaker.State(), pipe.BreakerHalfOpen, "Only the probe closes the circuit")
+		_, err = blocking(pool, 3)
+		td.CmpErrorIs(t, err, pipe.ErrCircuitOpen, "The probe is still running")
+		close(release[2])
+		wg.Wait()
+		td.Cmp(t, breaker.State(), pipe.BreakerClosed)
+	})
+
+	t.Run("observer_reads_state", func(t *testing.T) {
+		// Arrange
+		reader := stateReader{breaker: pipe.NewCircuitBreaker("api", 1, time.Hour)}
+		pool := InitPoolWithConfig(t, []int{1}, pipe.WithObserver(&reader))
+		var d down
+
+		// Act
+		err := pipe.RunErr(pool, lo.SliceToChannel(0, []int{1}), pipe.Breaker(reader.breaker, process(&d), nil), nil)
+
+		// Assert
+		td.CmpErrorIs(t, err, errOdd)
+		td.Cmp(t, reader.states, []pipe.BreakerState{pipe.BreakerOpen}, "The observer is notified once the breaker is unlocked")
+	})
+
+	t.Run("collector", func(t *testing.T) {
+		// Arrange
+		collector := pipe.NewCollector()
+		pool := InitPoolWithConfig(t, []int{1}, pipe.WithObserver(collector))
+		breaker := pipe.NewCircuitBreaker("api", 1, time.Hour)
+		var d down
+
+		// Act
+		_ = pipe.RunErr(pool, lo.SliceToChannel(0, lo.Range(3)), pipe.Breaker(breaker, process(&d), nil), nil)
+
+		// Assert
+		td.Cmp(t, collector.Breakers(), []pipe.BreakerMetrics{{Name: "api", State: pipe.BreakerOpen, Opened: 1}})
+		td.Cmp(t, pipe.BreakerHalfOpen.String(), "half-open")
+	})
+}
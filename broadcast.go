@@ -0,0 +1,68 @@
+package pipe
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/samber/lo"
+)
+
+// Tee duplicates in into n outputs. Each item is sent to every output before the next item is read, in any order,
+// so the slowest output sets the pace. The outputs are closed once in is closed.
+func Tee[T any](in <-chan T, n int) []<-chan T {
+	outs := lo.Times(n, func(int) chan T { return make(chan T) })
+	go func() {
+		defer func() {
+			for _, out := range outs {
+				close(out)
+			}
+		}()
+		cases := make([]reflect.SelectCase, n)
+		for item := range in {
+			value := reflect.ValueOf(&item).Elem() // keeps the type of nil interfaces
+			for i, out := range outs {
+				cases[i] = reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(out), Send: value}
+			}
+			for remaining := n; remaining > 0; remaining-- {
+				sent, _, _ := reflect.Select(cases)
+				cases[sent].Chan = reflect.Value{} // ignored from now on
+			}
+		}
+	}()
+	return lo.Map(outs, func(out chan T, _ int) <-chan T { return out })
+}
+
+// Branch is a branch of a Broadcast: a process running in its own pools.
+type Branch[T any] struct {
+	Pools   *Pools
+	Process PoolProcess[T]
+}
+
+// Broadcast sends every item of in to each branch, which processes it in its own pools like Pipe. It returns the outputs of the branches,
+// which must all be consumed, for instance through FanIn.
+func Broadcast[T any](in <-chan T, branches ...Branch[T]) []<-chan T {
+	outs := Tee(in, len(branches))
+	return lo.Map(branches, func(branch Branch[T], i int) <-chan T {
+		return Pipe(branch.Pools, outs[i], branch.Process)
+	})
+}
+
+// FanIn merges several channels into one, which is closed once all of them are closed.
+func FanIn[T any](ins ...<-chan T) <-chan T {
+	out := make(chan T)
+	var wg sync.WaitGroup
+	wg.Add(len(ins))
+	for _, in := range ins {
+		go func(in <-chan T) {
+			defer wg.Done()
+			for item := range in {
+				out <- item
+			}
+		}(in)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
@@ -0,0 +1,111 @@
+package pipe_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+func TestTee(t *testing.T) {
+	t.Run("duplicate", func(t *testing.T) {
+		// Arrange
+		in := lo.SliceToChannel(0, lo.Range(5))
+
+		// Act
+		outs := pipe.Tee(in, 3)
+
+		// Assert
+		td.Require(t).Len(outs, 3)
+		results := make([][]int, 3)
+		var wg sync.WaitGroup
+		for i, out := range outs {
+			wg.Add(1)
+			go func(i int, out <-chan int) {
+				defer wg.Done()
+				results[i] = lo.ChannelToSlice(out)
+			}(i, out)
+		}
+		wg.Wait()
+		td.Cmp(t, results, [][]int{lo.Range(5), lo.Range(5), lo.Range(5)})
+	})
+
+	t.Run("any_read_order", func(t *testing.T) {
+		// Arrange
+		in := lo.SliceToChannel(0, []int{1})
+
+		// Act
+		outs := pipe.Tee(in, 2)
+
+		// Assert
+		td.Cmp(t, <-outs[1], 1)
+		td.Cmp(t, <-outs[0], 1)
+	})
+}
+
+func TestBroadcast(t *testing.T) {
+	t.Run("branches", func(t *testing.T) {
+		// Arrange
+		indexer, archiver := InitPool(t, 2), InitPool(t, 1)
+		var mutex sync.Mutex
+		var indexed, archived []int
+		in := lo.SliceToChannel(0, lo.Range(10))
+
+		// Act
+		outs := pipe.Broadcast(in,
+			pipe.Branch[int]{Pools: indexer, Process: func(_ *pipe.Pools, i int) int {
+				mutex.Lock()
+				defer mutex.Unlock()
+				indexed = append(indexed, i)
+				return i
+			}},
+			pipe.Branch[int]{Pools: archiver, Process: func(_ *pipe.Pools, i int) int {
+				mutex.Lock()
+				defer mutex.Unlock()
+				archived = append(archived, i)
+				return -i
+			}},
+		)
+		results := lo.ChannelToSlice(pipe.FanIn(outs...))
+
+		// Assert
+		td.Cmp(t, results, td.Bag(lo.ToAnySlice(append(lo.Range(10), lo.Map(lo.Range(10), func(i, _ int) int { return -i })...))...))
+		td.Cmp(t, indexed, td.Bag(lo.ToAnySlice(lo.Range(10))...))
+		td.Cmp(t, archived, td.Bag(lo.ToAnySlice(lo.Range(10))...))
+	})
+}
+
+func TestFanIn(t *testing.T) {
+	t.Run("merge", func(t *testing.T) {
+		// Arrange
+		ins := []<-chan int{lo.SliceToChannel(0, []int{1, 2}), lo.SliceToChannel(0, []int{}), lo.SliceToChannel(0, []int{3})}
+
+		// Act
+		out := pipe.FanIn(ins...)
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), td.Bag(1, 2, 3))
+	})
+
+	t.Run("run", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2)
+		var mutex sync.Mutex
+		var seen []int
+
+		// Act
+		err := pipe.Run(pool, pipe.FanIn(lo.SliceToChannel(0, []int{1}), lo.SliceToChannel(0, []int{2})), func(_ *pipe.Pools, i int) int {
+			mutex.Lock()
+			defer mutex.Unlock()
+			seen = append(seen, i)
+			return i
+		})
+
+		// Assert
+		td.CmpNoError(t, err)
+		td.Cmp(t, seen, td.Bag(1, 2))
+		td.CmpEmpty(t, lo.ChannelToSlice(pipe.FanIn[int]()), "No input is closed at once")
+	})
+}
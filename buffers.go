@@ -0,0 +1,106 @@
+package pipe
+
+// bufferSizes are the sizes of the channels of a depth.
+type bufferSizes struct {
+	in  int // channel of the childs split by Wrap, towards the pool of the depth
+	out int // outputs of the pool of the depth, sent by Pipe
+}
+
+// WithBuffers sets the sizes of the buffered channels of a depth: in for the channels of the childs split by Wrap into this depth,
+// out for the outputs of the tasks of this depth, sent by Pipe and PipeErr. Channels are unbuffered by default.
+//
+// Like the pool sizes, buffers trade memory for throughput: they let a bursty Split or a slow Merge go on without lock-step handoffs between the depths.
+func WithBuffers(depth, in, out int) PoolsOption {
+	return func(c *poolsConfig) {
+		if c.buffers == nil {
+			c.buffers = map[int]bufferSizes{}
+		}
+		c.buffers[depth] = bufferSizes{in: in, out: out}
+	}
+}
+
+// WithBufferSizes returns a shallow copy of the pools, whose Pipe and Wrap calls use channels of the given sizes at the current depth,
+// instead of the sizes set by WithBuffers. The next depths keep their sizes.
+func (p *Pools) WithBufferSizes(in, out int) *Pools {
+	sizes := &bufferSizes{in: in, out: out}
+	if p == nil {
+		return &Pools{buffers: sizes}
+	}
+	result := *p
+	result.buffers = sizes
+	return &result
+}
+
+// bufferSizes returns the sizes of the channels of the current depth.
+func (p *Pools) bufferSizes() bufferSizes {
+	switch {
+	case p == nil:
+		return bufferSizes{}
+	case p.buffers != nil:
+		return *p.buffers
+	case p.config == nil:
+		return bufferSizes{}
+	}
+	return p.config.buffers[p.depth]
+}
+
+// trackBuffer registers a buffered channel of the current depth, whose occupancy is given by the Stats.
+// The returned function tells the channel is closed: it is unregistered at once if it is drained,
+// otherwise by the next registration or Stats of the depth which finds it drained.
+func (p *Pools) trackBuffer(in bool, occupancy func() int) func() {
+	if p == nil || p.config == nil {
+		return func() {}
+	}
+	stats := p.config.level(p.depth)
+	b := &buffer{in: in, occupancy: occupancy}
+	stats.mutex.Lock()
+	defer stats.mutex.Unlock()
+	stats.sweep()
+	if stats.buffers == nil {
+		stats.buffers = map[*buffer]struct{}{}
+	}
+	stats.buffers[b] = struct{}{}
+	return func() {
+		stats.mutex.Lock()
+		defer stats.mutex.Unlock()
+		delete(stats.buffers, b)
+		if b.occupancy() > 0 { // nothing is sent once closed, the reader drains it
+			if stats.draining == nil {
+				stats.draining = map[*buffer]struct{}{}
+			}
+			stats.draining[b] = struct{}{}
+		}
+	}
+}
+
+// buffer is a buffered channel of a depth.
+type buffer struct {
+	in        bool
+	occupancy func() int
+}
+
+// sweep unregisters the closed buffers drained by their reader. The mutex must be held.
+func (s *levelStats) sweep() {
+	for buffer := range s.draining {
+		if buffer.occupancy() == 0 {
+			delete(s.draining, buffer)
+		}
+	}
+}
+
+// buffered returns the number of items in the buffered channels of a depth.
+func (s *levelStats) buffered() (in, out int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.sweep()
+	for _, buffers := range []map[*buffer]struct{}{s.buffers, s.draining} {
+		for buffer := range buffers {
+			if buffer.in {
+				in += buffer.occupancy()
+			} else {
+				out += buffer.occupancy()
+			}
+		}
+	}
+	return in, out
+}
@@ -0,0 +1,102 @@
+package pipe_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+func TestBuffers(t *testing.T) {
+	t.Run("buffered_outputs", func(t *testing.T) {
+		// Arrange
+		pool := InitPoolWithConfig(t, []int{2}, pipe.WithBuffers(0, 0, 3))
+
+		// Act
+		out := pipe.Pipe(pool, lo.SliceToChannel(0, []int{1, 2, 3}), identity[int])
+
+		// Assert
+		td.Cmp(t, cap(out), 3)
+		td.Require(t).True(waitFor(func() bool { return pool.Stats()[0].BufferedOut == 3 }), "Outputs wait in the buffer without a reader")
+		td.Cmp(t, lo.ChannelToSlice(out), td.Bag(1, 2, 3))
+		td.Cmp(t, pool.Stats()[0].BufferedOut, 0)
+	})
+
+	t.Run("buffered_childs", func(t *testing.T) {
+		// Arrange
+		pool := InitPoolWithConfig(t, []int{1, 1}, pipe.WithBuffers(1, 4, 0))
+		gate := make(chan struct{})
+		split := make(chan struct{})
+		dispatcher, _ := pipe.NewDispatch(func(n int, in chan<- int) {
+			for i := 0; i < n; i++ {
+				in <- i
+			}
+			close(split)
+		}, func(_ int, out <-chan int) int {
+			return lo.Sum(lo.ChannelToSlice(out))
+		})
+		proc := pipe.Wrap(func(_ *pipe.Pools, i int) int {
+			<-gate
+			return i
+		}, dispatcher)
+
+		// Act
+		out := pipe.Pipe(pool, lo.SliceToChannel(0, []int{4}), proc)
+
+		// Assert
+		<-split // the split is over while the childs are still blocked
+		td.Require(t).True(waitFor(func() bool { return pool.Stats()[1].BufferedIn == 2 }), "A child is running, another one waits for a worker")
+		close(gate)
+		td.Cmp(t, lo.ChannelToSlice(out), []int{6})
+		td.Cmp(t, pool.Stats()[1].BufferedIn, 0)
+	})
+
+	t.Run("unregistered_once_drained", func(t *testing.T) {
+		// Arrange
+		pool := InitPoolWithConfig(t, []int{2, 2}, pipe.WithBuffers(0, 0, 2), pipe.WithBuffers(1, 2, 2))
+		dispatcher, _ := pipe.NewDispatch(func(n int, in chan<- int) {
+			for i := 0; i < n; i++ {
+				in <- i
+			}
+		}, func(_ int, out <-chan int) int {
+			return lo.Sum(lo.ChannelToSlice(out))
+		})
+		tracked := func() int { return pool.TrackedBuffers(0) + pool.TrackedBuffers(1) }
+
+		// Act
+		out := pipe.Pipe(pool, lo.SliceToChannel(0, lo.Range(50)), pipe.Wrap(identity[int], dispatcher))
+		lo.ChannelToSlice(out)
+
+		// Assert
+		td.Cmp(t, waitFor(func() bool { return tracked() <= 2 }), true, "Without any Stats, only the last closed buffers may wait to be swept")
+		td.Cmp(t, waitFor(func() bool { pool.Stats(); return tracked() == 0 }), true, "Stats sweeps the drained buffers")
+	})
+
+	t.Run("per_call_sizes", func(t *testing.T) {
+		// Arrange
+		pool := InitPoolWithConfig(t, []int{1, 1}, pipe.WithBuffers(0, 0, 5), pipe.WithBuffers(1, 0, 2))
+
+		// Act
+		out := pipe.Pipe(pool.WithBufferSizes(0, 1), lo.SliceToChannel(0, []int{1}), func(dp *pipe.Pools, i int) int {
+			inner := pipe.Pipe(dp, lo.SliceToChannel(0, []int{i}), identity[int])
+			td.Cmp(t, cap(inner), 2, "The next depths keep their sizes")
+			return lo.Sum(lo.ChannelToSlice(inner))
+		})
+
+		// Assert
+		td.Cmp(t, cap(out), 1)
+		td.Cmp(t, lo.ChannelToSlice(out), []int{1})
+	})
+}
+
+// waitFor polls cond until it holds, or gives up after a second.
+func waitFor(cond func() bool) bool {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if cond() {
+			return true
+		}
+	}
+	return false
+}
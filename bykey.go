@@ -0,0 +1,104 @@
+package pipe
+
+import (
+	"errors"
+	"sync"
+)
+
+// PipeByKey is like Pipe, but the items of a same key are processed one by one, in the order of the inputs, while the items of different keys
+// run in parallel in the pools. The items of a key are processed by a single task, so do may update a state of the key without locking it.
+//
+// The window bounds the number of items between their dispatch and their processing, so the number of active keys and the memory are bounded:
+// the dispatch waits once window items are pending. Once the context of the pools is done, the pending items are discarded.
+// A panic of do loses its item, like in Pipe, but not the next items of its key: the task panics once they are processed.
+func PipeByKey[K comparable, IN, OUT any](dp *Pools, in <-chan IN, key func(IN) K, do func(*Pools, IN) OUT, window int) <-chan OUT {
+	if window < 1 {
+		window = 1
+	}
+	out := make(chan OUT)
+
+	go func() {
+		var wg sync.WaitGroup
+		var mutex sync.Mutex
+		queues := map[K][]IN{} // pending items of the active keys, behind the running one
+		slots := make(chan struct{}, window)
+		ctx := dp.Context()
+		process := recovering(dp, func(dp *Pools, value IN) (OUT, error) { return do(dp, value), nil })
+		// next returns the next pending item of an active key, or deactivates the key
+		next := func(k K) (value IN, ok bool) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			queue := queues[k]
+			if ctx.Err() != nil {
+				for range queue {
+					<-slots
+				}
+				queue = nil
+			}
+			if len(queue) == 0 {
+				delete(queues, k)
+				return value, false
+			}
+			value, queues[k] = queue[0], queue[1:]
+			return value, true
+		}
+
+	dispatching:
+		for {
+			select {
+			case <-ctx.Done():
+				break dispatching
+			case slots <- struct{}{}:
+			}
+			var value IN
+			var ok bool
+			select {
+			case <-ctx.Done():
+				break dispatching
+			case value, ok = <-in:
+				if !ok {
+					break dispatching
+				}
+			}
+			k := key(value)
+			mutex.Lock()
+			if queue, active := queues[k]; active {
+				queues[k] = append(queue, value)
+				mutex.Unlock()
+				continue
+			}
+			queues[k] = nil
+			mutex.Unlock()
+			wg.Add(1)
+			err := dp.submit(itemKey(value), func(dp *Pools) error {
+				var panicked error
+				for value, ok := value, true; ok; value, ok = next(k) {
+					if result, err := process(dp, value); err == nil {
+						out <- result
+					} else if panicked == nil {
+						panicked = err
+					}
+					<-slots
+				}
+				if panicked != nil {
+					panic(panicked) // the *PanicError keeps the stack of the first panic
+				}
+				return nil
+			}, wg.Done)
+			if err != nil {
+				mutex.Lock()
+				delete(queues, k) // nothing was queued meanwhile, the dispatch is sequential
+				mutex.Unlock()
+				<-slots
+				if !errors.Is(err, errDropped) {
+					break dispatching // the failure was reported by submit
+				}
+			}
+		}
+		// Wait for all submitted task were done, to close out channel
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
@@ -0,0 +1,91 @@
+package pipe_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+// concurrent counts a running call, keeping the maximum of the concurrent calls. The returned function ends the call.
+func concurrent(running, maxRunning *atomic.Int32) func() {
+	n := running.Add(1)
+	for m := maxRunning.Load(); n > m && !maxRunning.CompareAndSwap(m, n); m = maxRunning.Load() { //nolint:revive
+	}
+	return func() { running.Add(-1) }
+}
+
+func TestPipeByKey(t *testing.T) {
+	type order struct {
+		customer int
+		seq      int
+	}
+	customer := func(o order) int { return o.customer }
+
+	t.Run("serial_by_key", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 4)
+		var orders []order
+		for seq := 0; seq < 20; seq++ {
+			for c := 0; c < 3; c++ {
+				orders = append(orders, order{c, seq})
+			}
+		}
+		seen := map[int]*[]int{0: {}, 1: {}, 2: {}} // updated without lock: a key is processed by a single task
+		var running, maxRunning atomic.Int32
+
+		// Act
+		out := pipe.PipeByKey(pool, lo.SliceToChannel(0, orders), customer, func(_ *pipe.Pools, o order) order {
+			defer concurrent(&running, &maxRunning)()
+			time.Sleep(time.Millisecond)
+			*seen[o.customer] = append(*seen[o.customer], o.seq)
+			return o
+		}, 10)
+
+		// Assert
+		td.CmpLen(t, lo.ChannelToSlice(out), 60)
+		for c := 0; c < 3; c++ {
+			td.Cmp(t, *seen[c], lo.Range(20), "Items of customer %d are processed in order", c)
+		}
+		td.Cmp(t, maxRunning.Load(), td.Between(int32(2), int32(3)), "Keys run in parallel")
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2)
+		orders := lo.Map(lo.Range(10), func(i, _ int) order { return order{i % 2, i} })
+
+		// Act
+		out := pipe.PipeByKey(pool, lo.SliceToChannel(0, orders), customer, func(_ *pipe.Pools, o order) order {
+			if o.seq == 3 {
+				panic("boom")
+			}
+			return o
+		}, 4)
+
+		// Assert
+		td.Cmp(t, lo.Map(lo.ChannelToSlice(out), func(o order, _ int) int { return o.seq }), td.Bag(0, 1, 2, 4, 5, 6, 7, 8, 9),
+			"A panic loses its item only")
+	})
+
+	t.Run("window", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 4)
+		orders := lo.Map(lo.Range(20), func(c, _ int) order { return order{c, 0} })
+		var running, maxRunning atomic.Int32
+
+		// Act
+		out := pipe.PipeByKey(pool, lo.SliceToChannel(0, orders), customer, func(_ *pipe.Pools, o order) order {
+			defer concurrent(&running, &maxRunning)()
+			time.Sleep(time.Millisecond)
+			return o
+		}, 2)
+
+		// Assert
+		td.CmpLen(t, lo.ChannelToSlice(out), 20)
+		td.Cmp(t, maxRunning.Load(), td.Lte(int32(2)), "At most window keys are active")
+	})
+}
@@ -0,0 +1,209 @@
+package pipe
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the histogram buckets of a Collector built without buckets.
+var DefaultBuckets = []time.Duration{
+	time.Millisecond, 5 * time.Millisecond, 10 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond,
+	500 * time.Millisecond, time.Second, 5 * time.Second, 10 * time.Second,
+}
+
+// Histogram counts durations in buckets.
+type Histogram struct {
+	Buckets []time.Duration // upper bounds of the buckets, in increasing order
+	Counts  []int64         // cumulative counts: Counts[i] is the count of durations lower or equal to Buckets[i]
+	Count   int64
+	Sum     time.Duration
+}
+
+// observe adds a duration to the histogram.
+func (h *Histogram) observe(d time.Duration) {
+	for i := sort.Search(len(h.Buckets), func(i int) bool { return d <= h.Buckets[i] }); i < len(h.Buckets); i++ {
+		h.Counts[i]++
+	}
+	h.Count++
+	h.Sum += d
+}
+
+// clone returns a deep copy of the histogram.
+func (h Histogram) clone() Histogram {
+	h.Counts = slices.Clone(h.Counts)
+	return h
+}
+
+// Metrics are the metrics of the tasks of a depth and a stage name.
+type Metrics struct {
+	Depth      int
+	Name       string
+	Submitted  int64
+	Rejected   int64
+	Finished   int64
+	Errors     int64 // finished tasks whose item failed
+	Deferred   int64 // tasks whose item was deferred, like the failed attempts of a Retry
+	Panics     int64
+	Running    int64
+	Waiting    int64
+	Throughput float64   // finished tasks per second, since the creation of the collector
+	Wait       Histogram // queue wait time
+	Latency    Histogram // execution time
+}
+
+// BreakerMetrics are the metrics of a CircuitBreaker.
+type BreakerMetrics struct {
+	Name   string
+	State  BreakerState
+	Opened int64 // number of times the circuit opened
+}
+
+type seriesKey struct {
+	depth int
+	name  string
+}
+
+// Collector is an Observer which keeps the metrics of each depth and stage name, to tune the pool sizes.
+type Collector struct {
+	mutex    sync.Mutex
+	buckets  []time.Duration
+	created  time.Time
+	series   map[seriesKey]*Metrics
+	breakers map[string]*BreakerMetrics
+}
+
+// NewCollector creates a Collector, whose histograms have the given buckets (DefaultBuckets if none).
+func NewCollector(buckets ...time.Duration) *Collector {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	return &Collector{buckets: buckets, created: time.Now(), series: map[seriesKey]*Metrics{}, breakers: map[string]*BreakerMetrics{}}
+}
+
+// update applies f on the metrics of the event.
+func (c *Collector) update(ev Event, f func(*Metrics)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	key := seriesKey{ev.Depth, ev.Name}
+	m, ok := c.series[key]
+	if !ok {
+		m = &Metrics{
+			Depth:   ev.Depth,
+			Name:    ev.Name,
+			Wait:    Histogram{Buckets: c.buckets, Counts: make([]int64, len(c.buckets))},
+			Latency: Histogram{Buckets: c.buckets, Counts: make([]int64, len(c.buckets))},
+		}
+		c.series[key] = m
+	}
+	f(m)
+}
+
+// Submit implements Observer.
+func (c *Collector) Submit(ev Event) {
+	c.update(ev, func(m *Metrics) {
+		m.Submitted++
+		m.Waiting++
+	})
+}
+
+// Reject implements Observer.
+func (c *Collector) Reject(ev Event) {
+	c.update(ev, func(m *Metrics) {
+		m.Rejected++
+		m.Waiting--
+	})
+}
+
+// Start implements Observer.
+func (c *Collector) Start(ev Event) {
+	c.update(ev, func(m *Metrics) {
+		m.Waiting--
+		m.Running++
+		m.Wait.observe(ev.Wait)
+	})
+}
+
+// Finish implements Observer.
+func (c *Collector) Finish(ev Event) {
+	c.update(ev, func(m *Metrics) {
+		m.Running--
+		m.Finished++
+		if ev.Err != nil {
+			m.Errors++
+		}
+		m.Latency.observe(ev.Elapsed)
+	})
+}
+
+// Defer implements Observer.
+func (c *Collector) Defer(ev Event) {
+	c.update(ev, func(m *Metrics) {
+		m.Running--
+		m.Deferred++
+		m.Latency.observe(ev.Elapsed)
+	})
+}
+
+// Panic implements Observer.
+func (c *Collector) Panic(ev Event) {
+	c.update(ev, func(m *Metrics) {
+		m.Running--
+		m.Panics++
+		m.Latency.observe(ev.Elapsed)
+	})
+}
+
+// BreakerChange implements BreakerObserver.
+func (c *Collector) BreakerChange(ev BreakerEvent) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	m, ok := c.breakers[ev.Name]
+	if !ok {
+		m = &BreakerMetrics{Name: ev.Name}
+		c.breakers[ev.Name] = m
+	}
+	m.State = ev.To
+	if ev.To == BreakerOpen {
+		m.Opened++
+	}
+}
+
+// Breakers returns a copy of the metrics of the circuit breakers which changed state, sorted by name.
+func (c *Collector) Breakers() []BreakerMetrics {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	result := make([]BreakerMetrics, 0, len(c.breakers))
+	for _, m := range c.breakers {
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Snapshot returns a copy of the metrics, sorted by depth and stage name.
+func (c *Collector) Snapshot() []Metrics {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	elapsed := time.Since(c.created).Seconds()
+	result := make([]Metrics, 0, len(c.series))
+	for _, m := range c.series {
+		copied := *m
+		copied.Wait = m.Wait.clone()
+		copied.Latency = m.Latency.clone()
+		if elapsed > 0 {
+			copied.Throughput = float64(m.Finished) / elapsed
+		}
+		result = append(result, copied)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Depth != result[j].Depth {
+			return result[i].Depth < result[j].Depth
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
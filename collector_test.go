@@ -0,0 +1,88 @@
+package pipe_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+func TestCollector(t *testing.T) {
+	t.Run("collect_by_depth_and_name", func(t *testing.T) {
+		// Arrange
+		collector := pipe.NewCollector(time.Millisecond, time.Hour)
+		pool := InitPoolWithConfig(t, []int{2, 2}, pipe.WithObserver(collector))
+		in := lo.SliceToChannel(0, []int{2, 3})
+		dispatcher, _ := pipe.NewDispatch(func(parent int, in chan<- int) {
+			for i := 0; i < parent; i++ {
+				in <- i
+			}
+		}, func(parent int, out <-chan int) int {
+			_ = lo.ChannelToSlice(out)
+			return parent
+		})
+		slow := func(_ *pipe.Pools, i int) (int, error) {
+			time.Sleep(2 * time.Millisecond)
+			return failOdd(nil, i)
+		}
+
+		// Act
+		err := pipe.RunErr(pool, in, pipe.WrapErr(slow, dispatcher, pipe.DropErrors[int]()), nil)
+
+		// Assert
+		td.CmpNoError(t, err)
+		metrics := collector.Snapshot()
+		td.Cmp(t, metrics, td.Slice([]pipe.Metrics{}, td.ArrayEntries{
+			0: td.SStruct(pipe.Metrics{Depth: 0, Submitted: 2, Finished: 2}, td.StructFields{
+				"Throughput": td.Gt(0.0),
+				"Wait":       td.Ignore(),
+				"Latency":    td.Smuggle("Count", int64(2)),
+			}),
+			1: td.SStruct(pipe.Metrics{Depth: 1, Submitted: 5, Finished: 5, Errors: 2}, td.StructFields{
+				"Throughput": td.Gt(0.0),
+				"Wait":       td.Smuggle("Count", int64(5)),
+				"Latency": td.SStruct(pipe.Histogram{
+					Buckets: []time.Duration{time.Millisecond, time.Hour},
+					Counts:  []int64{0, 5},
+					Count:   5,
+				}, td.StructFields{"Sum": td.Gte(10 * time.Millisecond)}),
+			}),
+		}))
+	})
+
+	t.Run("deferred_items", func(t *testing.T) {
+		// Arrange
+		collector := pipe.NewCollector()
+		pool := InitPoolWithConfig(t, []int{2}, pipe.WithObserver(collector))
+		proc := flaky{failures: 2}
+
+		// Act
+		err := pipe.RunErr(pool, lo.SliceToChannel(0, []int{1}), pipe.Retry(proc.process, 3, pipe.ConstantBackoff(time.Millisecond), nil), nil)
+
+		// Assert
+		td.CmpNoError(t, err)
+		td.Cmp(t, collector.Snapshot(), td.Slice([]pipe.Metrics{}, td.ArrayEntries{
+			0: td.SStruct(pipe.Metrics{Submitted: 3, Finished: 1, Deferred: 2}, td.StructFields{
+				"Throughput": td.Gt(0.0),
+				"Wait":       td.Ignore(),
+				"Latency":    td.Smuggle("Count", int64(3)),
+			}),
+		}), "The failed attempts are not processed items")
+	})
+
+	t.Run("default_buckets", func(t *testing.T) {
+		// Arrange
+		collector := pipe.NewCollector()
+
+		// Act
+		collector.Start(pipe.Event{Name: "stage", Wait: time.Minute})
+
+		// Assert
+		td.Cmp(t, collector.Snapshot(), td.Slice([]pipe.Metrics{}, td.ArrayEntries{0: td.SStruct(pipe.Metrics{Name: "stage", Running: 1, Waiting: -1}, td.StructFields{
+			"Wait":    td.Smuggle("Counts", make([]int64, len(pipe.DefaultBuckets))),
+			"Latency": td.Ignore(),
+		})}))
+	})
+}
@@ -0,0 +1,63 @@
+package pipe
+
+import (
+	"context"
+)
+
+// SplitContext is a Split which receives the context of the pipeline. It should stop producing childs when the context is done.
+type SplitContext[Parent, Child any] func(ctx context.Context, parent Parent, in chan<- Child)
+
+// MergeContext is a Merge which receives the context of the pipeline.
+type MergeContext[Parent, Child any] func(ctx context.Context, parent Parent, out <-chan Child) Parent
+
+// NewDispatchContext creates a Dispatch from context aware Split and Merge functions.
+func NewDispatchContext[Parent, Child any](split SplitContext[Parent, Child], merge MergeContext[Parent, Child]) (Dispatch[Parent, Child], error) {
+	result := Dispatch[Parent, Child]{split: split, merge: merge}
+	return result, result.Validate()
+}
+
+// Context returns the context of the pools. It is never nil, it defaults to context.Background.
+//
+// The context is passed down to the pools given to every PoolProcess, so processes can be cancelled at any depth.
+func (p *Pools) Context() context.Context {
+	if p == nil || p.ctx == nil {
+		return context.Background()
+	}
+	return p.ctx
+}
+
+// WithContext returns a shallow copy of the pools bound to ctx. Pipe, Wrap and Run stop submitting tasks once ctx is done.
+func (p *Pools) WithContext(ctx context.Context) *Pools {
+	if p == nil {
+		return &Pools{ctx: ctx}
+	}
+	result := *p
+	result.ctx = ctx
+	return &result
+}
+
+// PipeContext is like Pipe, but it stops submitting tasks once ctx is done. The output channel is closed when all the submitted tasks are done.
+func PipeContext[IN, OUT any](ctx context.Context, dp *Pools, in <-chan IN, do func(*Pools, IN) OUT) <-chan OUT {
+	return Pipe(dp.WithContext(ctx), in, do)
+}
+
+// WrapContext is like Wrap, but the childs of each parent are bound to ctx as well as to the context of the pools:
+// once either is done, no more child is submitted, and the Split and Merge of a Dispatch built by NewDispatchContext see it.
+func WrapContext[Parent, Child any](ctx context.Context, procs PoolProcess[Child], dispatch Dispatch[Parent, Child]) PoolProcess[Parent] {
+	wrapped := Wrap(procs, dispatch)
+	return func(pool *Pools, p Parent) Parent {
+		bound, cancel := context.WithCancel(pool.Context())
+		defer cancel()
+		stop := context.AfterFunc(ctx, cancel)
+		defer stop()
+		if ctx.Err() != nil { // AfterFunc cancels asynchronously
+			cancel()
+		}
+		return wrapped(pool.WithContext(bound), p)
+	}
+}
+
+// RunContext is like Run, but it stops submitting tasks once ctx is done. It waits for the submitted tasks, then returns the context error if the input was not fully consumed.
+func RunContext[T any](ctx context.Context, pool *Pools, in <-chan T, proc PoolProcess[T]) error {
+	return Run(pool.WithContext(ctx), in, proc)
+}
@@ -0,0 +1,140 @@
+package pipe_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+// endless returns a channel which is never closed, sending its value until ctx is done.
+func endless[T any](ctx context.Context, value T) <-chan T {
+	in := make(chan T)
+	go func() {
+		for {
+			select {
+			case in <- value:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return in
+}
+
+func TestContext(t *testing.T) {
+	type key struct{}
+
+	t.Run("default_context", func(t *testing.T) {
+		// Arrange
+		var pool *pipe.Pools
+
+		// Act
+		ctx := pool.Context()
+
+		// Assert
+		td.Cmp(t, ctx, context.Background())
+	})
+
+	t.Run("context_flows_to_processes", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1, 1)
+		ctx := context.WithValue(context.Background(), key{}, "value")
+		in := lo.SliceToChannel(0, []int{1})
+		var splitValue, mergeValue any
+		dispatcher, _ := pipe.NewDispatchContext(func(ctx context.Context, parent int, in chan<- int) {
+			splitValue = ctx.Value(key{})
+			in <- parent
+		}, func(ctx context.Context, parent int, out <-chan int) int {
+			mergeValue = ctx.Value(key{})
+			return parent + len(lo.ChannelToSlice(out))
+		})
+		child := func(p *pipe.Pools, i int) int {
+			td.Cmp(t, p.Context().Value(key{}), "value")
+			return i
+		}
+
+		// Act
+		err := pipe.RunContext(ctx, pool, in, pipe.Wrap(child, dispatcher))
+
+		// Assert
+		td.CmpNoError(t, err)
+		td.Cmp(t, splitValue, "value")
+		td.Cmp(t, mergeValue, "value")
+	})
+
+	t.Run("run_context_cancelled", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		in := endless(ctx, 1)
+		var count atomic.Int32
+		proc := func(_ *pipe.Pools, i int) int {
+			count.Add(1)
+			cancel()
+			return i
+		}
+
+		// Act
+		err := pipe.RunContext(ctx, pool, in, proc)
+
+		// Assert
+		td.CmpErrorIs(t, err, context.Canceled)
+		td.Cmp(t, count.Load(), td.Between(int32(1), int32(2))) // The pool may have started a second task before cancellation
+	})
+
+	t.Run("pipe_context_cancelled_childs", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1, 2)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		in := lo.SliceToChannel(0, []int{1})
+		// A long split, which doesn't care about the context
+		dispatcher, _ := pipe.NewDispatch(func(parent int, in chan<- int) {
+			for i := 0; i < 1000; i++ {
+				in <- parent
+			}
+		}, func(parent int, out <-chan int) int {
+			<-out
+			cancel()
+			return parent
+		})
+
+		// Act
+		out := pipe.PipeContext(ctx, pool, in, pipe.Wrap(identity[int], dispatcher))
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), []int{1})
+	})
+
+	t.Run("wrap_context_cancelled", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1, 2)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		var count atomic.Int32
+		splitErr := make(chan error, 1)
+		dispatcher, _ := pipe.NewDispatchContext(func(ctx context.Context, parent int, in chan<- int) {
+			splitErr <- ctx.Err()
+			in <- parent
+		}, func(_ context.Context, _ int, out <-chan int) int {
+			return len(lo.ChannelToSlice(out))
+		})
+		child := func(_ *pipe.Pools, i int) int {
+			count.Add(1)
+			return i
+		}
+
+		// Act
+		out := pipe.Pipe(pool, lo.SliceToChannel(0, []int{1}), pipe.WrapContext(ctx, child, dispatcher))
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), []int{0}, "The parent goes on, without its childs")
+		td.Cmp(t, count.Load(), int32(0))
+		td.CmpErrorIs(t, <-splitErr, context.Canceled)
+	})
+}
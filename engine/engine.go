@@ -0,0 +1,127 @@
+/*
+engine builds reusable pipelines from pipe building blocks, with any depth of Split and Merge.
+
+A Builder collects processes for one type. Split opens the Builder of a nested level, Merge closes it and returns to its parent Builder:
+
+	b := engine.New[Job]().Process(prepare)
+	sub := engine.Split(b, splitJob).Process(handleSubJob)
+	b = engine.Merge(sub, mergeJob).Process(finish)
+	e, err := b.Build([]int{4, 16})
+
+Build errors are collected along the way and returned by Build, instead of panicking.
+*/
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fogfactory/pipe"
+)
+
+var (
+	// ErrInvalidNesting is returned by Build when Split and Merge are not balanced or do not match their types.
+	ErrInvalidNesting = errors.New("invalid nesting")
+	// ErrDepthMismatch is returned by Build when the pool sizes don't match the nesting depth of the pipeline.
+	ErrDepthMismatch = pipe.ErrDepthMismatch
+)
+
+// build holds the state shared by all the builders of a pipeline.
+type build struct {
+	errs []error
+}
+
+// Builder builds the pipeline of T items at one nesting depth.
+type Builder[T any] struct {
+	build  *build
+	depth  int
+	stages []pipe.Stage[T]
+	parent any // *Builder[Parent] of a nested level
+	split  any // pipe.Split[Parent, T] of a nested level
+}
+
+// New creates the Builder of the top level of a pipeline.
+func New[T any]() *Builder[T] {
+	return &Builder[T]{build: &build{}}
+}
+
+// Process adds processes to the current level.
+func (b *Builder[T]) Process(procs ...pipe.Process[T]) *Builder[T] {
+	return b.PoolProcess(pipe.AsPoolProcesses(procs...)...)
+}
+
+// PoolProcess adds pool processes to the current level.
+func (b *Builder[T]) PoolProcess(procs ...pipe.PoolProcess[T]) *Builder[T] {
+	b.stages = append(b.stages, pipe.NewStage(procs...))
+	return b
+}
+
+// Split opens a nested level: each Parent item is split into Child items, processed in the next level pool. The nested level is closed by Merge.
+func Split[Parent, Child any](b *Builder[Parent], split pipe.Split[Parent, Child]) *Builder[Child] {
+	return &Builder[Child]{build: b.build, depth: b.depth + 1, parent: b, split: split}
+}
+
+// Merge closes a nested level opened by Split, and returns the Builder of the parent level.
+func Merge[Parent, Child any](b *Builder[Child], merge pipe.Merge[Parent, Child]) *Builder[Parent] {
+	parent, ok := b.parent.(*Builder[Parent])
+	if !ok {
+		b.errorf("%w: merge of %T into %T at depth %d doesn't close the split of %T", ErrInvalidNesting, *new(Child), *new(Parent), b.depth, b.parent)
+		return &Builder[Parent]{build: b.build, depth: b.depth - 1} // detached builder, to keep on building and collecting errors
+	}
+	split, _ := b.split.(pipe.Split[Parent, Child])
+	dispatch, err := pipe.NewDispatch(split, merge)
+	if err != nil {
+		b.errorf("%w at depth %d", err, b.depth)
+		return parent
+	}
+	parent.stages = append(parent.stages, pipe.WrapStage(pipe.LinkStages(b.stages...), dispatch))
+	return parent
+}
+
+// Build validates the pipeline and creates an Engine, with a pool size for each depth of the pipeline (see pipe.ValidateDepth).
+// It returns all the errors collected while building.
+func (b *Builder[T]) Build(poolSizes []int, opts ...pipe.PoolsOption) (*Engine[T], error) {
+	if b.parent != nil {
+		b.errorf("%w: split from depth %d not merged", ErrInvalidNesting, b.depth-1)
+	}
+	if err := errors.Join(b.build.errs...); err != nil {
+		return nil, err
+	}
+	pools, err := pipe.NewPoolsWithConfig(poolSizes, opts...)
+	if err != nil {
+		return nil, err
+	}
+	stage := pipe.LinkStages(b.stages...)
+	if err := stage.Validate(pools); err != nil {
+		pools.Release()
+		return nil, err
+	}
+	return &Engine[T]{pools: pools, stage: stage}, nil
+}
+
+// errorf collects a build error.
+func (b *Builder[T]) errorf(format string, args ...any) {
+	b.build.errs = append(b.build.errs, fmt.Errorf(format, args...))
+}
+
+// Engine runs a built pipeline. It can be run several times, even concurrently, until it is released.
+type Engine[T any] struct {
+	pools *pipe.Pools
+	stage pipe.Stage[T]
+}
+
+// Run runs the engine until the input channel is closed and all the items are processed.
+func (e *Engine[T]) Run(in <-chan T) error {
+	return e.RunContext(context.Background(), in)
+}
+
+// RunContext runs the engine until the input channel is closed and all the items are processed, or until ctx is done.
+func (e *Engine[T]) RunContext(ctx context.Context, in <-chan T) error {
+	return pipe.RunContext(ctx, e.pools, in, e.stage.Process)
+}
+
+// Release releases the pools of the engine.
+func (e *Engine[T]) Release() {
+	e.pools.Release()
+}
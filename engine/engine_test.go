@@ -0,0 +1,155 @@
+package engine_test
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fogfactory/pipe"
+	"github.com/fogfactory/pipe/engine"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+type Job struct {
+	text  string
+	words int
+}
+
+type SubJob struct {
+	line  string
+	words int
+}
+
+type Group struct {
+	word string
+}
+
+func splitLines(job Job, in chan<- SubJob) {
+	for _, line := range strings.Split(job.text, "\n") {
+		in <- SubJob{line: line}
+	}
+}
+
+func mergeLines(job Job, out <-chan SubJob) Job {
+	for sub := range out {
+		job.words += sub.words
+	}
+	return job
+}
+
+func splitWords(sub SubJob, in chan<- Group) {
+	for _, word := range strings.Fields(sub.line) {
+		in <- Group{word: word}
+	}
+}
+
+func mergeWords(sub SubJob, out <-chan Group) SubJob {
+	sub.words += len(lo.ChannelToSlice(out))
+	return sub
+}
+
+func TestEngine(t *testing.T) {
+	t.Run("success_nested_depth", func(t *testing.T) {
+		// Arrange
+		var total atomic.Int64
+		b := engine.New[Job]()
+		subJobs := engine.Split(b, splitLines)
+		groups := engine.Split(subJobs, splitWords)
+		subJobs = engine.Merge(groups, mergeWords)
+		b = engine.Merge(subJobs, mergeLines).Process(func(j Job) Job {
+			total.Add(int64(j.words))
+			return j
+		})
+
+		// Act
+		e, err := b.Build([]int{2, 2, 2})
+		td.Require(t).CmpNoError(err)
+		t.Cleanup(e.Release)
+		err = e.Run(lo.SliceToChannel(0, []Job{{text: "a b\nc"}, {text: "d\ne f g"}}))
+
+		// Assert
+		td.CmpNoError(t, err)
+		td.Cmp(t, total.Load(), int64(7))
+	})
+
+	t.Run("success_reusable", func(t *testing.T) {
+		// Arrange
+		var count atomic.Int64
+		e, err := engine.New[Job]().Process(func(j Job) Job {
+			count.Add(1)
+			return j
+		}).Build([]int{2})
+		td.Require(t).CmpNoError(err)
+		t.Cleanup(e.Release)
+
+		// Act
+		errs := []error{
+			e.Run(lo.SliceToChannel(0, []Job{{}, {}})),
+			e.Run(lo.SliceToChannel(0, []Job{{}})),
+		}
+
+		// Assert
+		td.Cmp(t, errs, []error{nil, nil})
+		td.Cmp(t, count.Load(), int64(3))
+	})
+
+	t.Run("error_depth_mismatch", func(t *testing.T) {
+		// Arrange
+		b := engine.Merge(engine.Split(engine.New[Job](), splitLines), mergeLines)
+
+		// Act
+		_, err := b.Build([]int{2})
+
+		// Assert
+		td.CmpErrorIs(t, err, engine.ErrDepthMismatch)
+	})
+
+	t.Run("error_unclosed_split", func(t *testing.T) {
+		// Arrange
+		b := engine.Split(engine.New[Job](), splitLines)
+
+		// Act
+		_, err := b.Build([]int{1, 1})
+
+		// Assert
+		td.CmpErrorIs(t, err, engine.ErrInvalidNesting)
+	})
+
+	t.Run("error_collected", func(t *testing.T) {
+		// Arrange
+		subJobs := engine.Split[Job, SubJob](engine.New[Job](), nil)
+		b := engine.Merge(subJobs, mergeLines)
+		_ = engine.Merge(engine.Split(b, splitLines), func(s SubJob, _ <-chan SubJob) SubJob { return s }) // SubJob doesn't close a split from Job
+
+		// Act
+		_, err := b.Build([]int{1, 1})
+
+		// Assert
+		td.CmpErrorIs(t, err, pipe.ErrInvalidDispatcher)
+		td.CmpErrorIs(t, err, engine.ErrInvalidNesting)
+	})
+}
+
+func ExampleEngine() {
+	b := engine.New[Job]()
+	subJobs := engine.Split(b, splitLines).Process(func(s SubJob) SubJob {
+		s.words = len(strings.Fields(s.line))
+		return s
+	})
+	b = engine.Merge(subJobs, mergeLines).Process(func(j Job) Job {
+		fmt.Println(j.words, "words")
+		return j
+	})
+
+	e, err := b.Build([]int{1, 4})
+	if err != nil {
+		panic(err)
+	}
+	defer e.Release()
+	_ = e.Run(lo.SliceToChannel(0, []Job{{text: "hello world\nhow are you"}}))
+
+	// Output:
+	// 5 words
+}
@@ -0,0 +1,301 @@
+package pipe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// ErrProcess defines a PoolProcess which may fail. When an error is returned, the returned value is ignored and the item is handled by an ErrorPolicy.
+type ErrProcess[T any] func(*Pools, T) (T, error)
+
+// ItemError is the error of a failed item. It keeps the item, so failed items can be routed or replayed.
+type ItemError[T any] struct {
+	Item T
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *ItemError[T]) Error() string {
+	return fmt.Sprintf("%T item failed: %v", e.Item, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ItemError[T]) Unwrap() error {
+	return e.Err
+}
+
+// ErrorPolicy decides what happens to an item whose process failed. The returned error is aggregated by the pipeline, a nil error drops the failure silently.
+type ErrorPolicy[T any] func(item T, err error) error
+
+// CollectErrors is the default ErrorPolicy: every failure is aggregated as an *ItemError.
+func CollectErrors[T any]() ErrorPolicy[T] {
+	return func(item T, err error) error {
+		return &ItemError[T]{Item: item, Err: err}
+	}
+}
+
+// DropErrors is an ErrorPolicy which silently drops failed items.
+func DropErrors[T any]() ErrorPolicy[T] {
+	return func(T, error) error { return nil }
+}
+
+// RouteErrors is an ErrorPolicy which sends failed items to the failed channel instead of aggregating them.
+// The channel must be consumed, otherwise the pipeline will block.
+func RouteErrors[T any](failed chan<- *ItemError[T]) ErrorPolicy[T] {
+	return func(item T, err error) error {
+		failed <- &ItemError[T]{Item: item, Err: err}
+		return nil
+	}
+}
+
+// AsErrProcess decorates a PoolProcess, in order to make it seen as an ErrProcess which never fails.
+func AsErrProcess[T any](proc PoolProcess[T]) ErrProcess[T] {
+	return func(pool *Pools, t T) (T, error) { return proc(pool, t), nil }
+}
+
+// AsErrProcesses is an helper function to call AsErrProcess on lists.
+func AsErrProcesses[T any](procs ...PoolProcess[T]) []ErrProcess[T] {
+	return lo.Map(procs, func(proc PoolProcess[T], _ int) ErrProcess[T] {
+		return AsErrProcess(proc)
+	})
+}
+
+// LinkErr merges several ErrProcess to one. The first error stops the chain. An item deferred by a process, like a Retry, goes on with the chain once resumed.
+func LinkErr[T any](procs ...ErrProcess[T]) ErrProcess[T] {
+	return func(pool *Pools, t T) (T, error) {
+		return linkErr(pool, t, procs)
+	}
+}
+
+// linkErr runs the chain of procs on t.
+func linkErr[T any](pool *Pools, t T, procs []ErrProcess[T]) (T, error) {
+	var err error
+	for i, proc := range procs {
+		if t, err = proc(pool, t); err != nil {
+			var later *Deferred
+			if errors.As(err, &later) {
+				rest := procs[i+1:]
+				return t, then(later, func(pool *Pools, t T) (T, error) { return linkErr(pool, t, rest) })
+			}
+			return t, err
+		}
+	}
+	return t, nil
+}
+
+// PipeErr is like Pipe, but do may fail. Failed items are not sent to the output channel: they are handed to the policy (CollectErrors if nil).
+// A panic of do fails its item with a *PanicError. An item deferred by do, like a Retry, is submitted again once its wait is over:
+// the pipe holds at most twice as many items as the workers of the depth, deferred ones included, then stops reading the inputs.
+// A failed submission stops the dispatch of the remaining inputs, unless the SubmitPolicy of the depth handles it.
+//
+// The error channel receives the aggregation of the errors returned by the policy once the output channel is closed, then it is closed.
+func PipeErr[IN, OUT any](dp *Pools, in <-chan IN, do func(*Pools, IN) (OUT, error), policy ErrorPolicy[IN]) (<-chan OUT, <-chan error) {
+	return pipeErr(dp, in, do, policy, true)
+}
+
+// pipeErr is the implementation of PipeErr, protect tells if the panics of do are recovered.
+func pipeErr[IN, OUT any](dp *Pools, in <-chan IN, do func(*Pools, IN) (OUT, error), policy ErrorPolicy[IN], protect bool) (<-chan OUT, <-chan error) {
+	if policy == nil {
+		policy = CollectErrors[IN]()
+	}
+	out := make(chan OUT, dp.bufferSizes().out)
+	errc := make(chan error, 1)
+	closed := func() {}
+	if cap(out) > 0 {
+		closed = dp.trackBuffer(false, func() int { return len(out) })
+	}
+
+	go func() {
+		var wg sync.WaitGroup
+		var mutex sync.Mutex
+		var errs []error
+		collect := func(err error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			errs = append(errs, err)
+		}
+		ctx := dp.Context()
+		call := func(pool *Pools, value IN, do func(*Pools, IN) (OUT, error)) (OUT, error) {
+			if protect {
+				do = recovering(dp, do)
+			}
+			return do(pool, value)
+		}
+		// slots bound the items in flight, deferred ones included: the inputs are not read anymore while they are all taken.
+		// The workers of the depth may process as many new items as there are deferred ones.
+		slots := make(chan struct{}, 2*max(dp.capacity(), 1))
+		// handle sends the result of an item, or hands its failure to the policy
+		handle := func(value IN, result OUT, err error) error {
+			if err == nil {
+				out <- result
+			} else if policyErr := policy(value, err); policyErr != nil {
+				collect(policyErr)
+			}
+			return err
+		}
+		var resume func(value IN, later *Deferred)
+		// submit submits the task of an item, which keeps its slot until it is over: a deferred item keeps it until it is resumed
+		submit := func(value IN, do func(*Pools, IN) (OUT, error)) error {
+			deferred := false
+			wg.Add(1)
+			return dp.submit(itemKey(value), func(pool *Pools) error {
+				result, err := call(pool, value, do)
+				var later *Deferred
+				if errors.As(err, &later) {
+					deferred = true
+					wg.Add(1)
+					go resume(value, later)
+					return err // the item is not over, see Observer.Defer
+				}
+				return handle(value, result, err)
+			}, func() {
+				if !deferred {
+					<-slots
+				}
+				wg.Done()
+			})
+		}
+		// resume waits for the delay of a deferred item, then submits it again. The wait is over as soon as the context is done.
+		resume = func(value IN, later *Deferred) {
+			defer wg.Done()
+			timer := time.NewTimer(later.Delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+			}
+			if ctx.Err() != nil { // the item won't be resumed
+				<-slots
+				if policyErr := policy(value, errors.Join(later.Err, ctx.Err())); policyErr != nil {
+					collect(policyErr)
+				}
+				return
+			}
+			err := submit(value, func(pool *Pools, _ IN) (OUT, error) {
+				return resumeAs[OUT](later, pool)
+			})
+			if err != nil && !errors.Is(err, errDropped) {
+				collect(err)
+			}
+		}
+	dispatching:
+		for {
+			select {
+			case <-ctx.Done():
+				collect(ctx.Err()) // remaining inputs won't be dispatched
+				break dispatching
+			case slots <- struct{}{}:
+			}
+			select {
+			case <-ctx.Done():
+				collect(ctx.Err())
+				break dispatching
+			case value, ok := <-in:
+				if !ok {
+					break dispatching
+				}
+				if ctx.Err() != nil { // both cases may be ready, cancellation wins
+					collect(ctx.Err())
+					break dispatching
+				}
+				if err := submit(value, do); err != nil {
+					if !errors.Is(err, errDropped) {
+						collect(err) // fail the pipeline
+						break dispatching
+					}
+				}
+			}
+		}
+		// Wait for all submitted task were done, to close out channel
+		wg.Wait()
+		close(out)
+		closed()
+		errc <- errors.Join(errs...)
+		close(errc)
+	}()
+
+	return out, errc
+}
+
+// WrapErr is like Wrap, but with an ErrProcess. Failed childs are handed to the policy (CollectErrors if nil) and are not merged.
+// The parent fails with the aggregated childs errors, after the merge of the successful childs.
+func WrapErr[Parent, Child any](procs ErrProcess[Child], dispatch Dispatch[Parent, Child], policy ErrorPolicy[Child]) ErrProcess[Parent] {
+	return func(pool *Pools, p Parent) (Parent, error) {
+		if err := dispatch.Validate(); err != nil {
+			return p, err
+		}
+		return wrap(pool, p, dispatch.split, procs, policy, dispatch.merge, dispatch.leak)
+	}
+}
+
+// wrap is the shared implementation of the Wrap functions: it splits the parent, processes the childs in the pools, then merges them.
+// The childs left over by the merge are handed to leak, or panic if it is nil.
+func wrap[Parent, Child, ChildOut, Out any](pool *Pools, p Parent,
+	split SplitContext[Parent, Child], procs func(*Pools, Child) (ChildOut, error), policy ErrorPolicy[Child], merge func(context.Context, Parent, <-chan ChildOut) Out,
+	leak LeakPolicy[Parent, ChildOut],
+) (Out, error) {
+	in := make(chan Child, pool.bufferSizes().in)
+	closed := func() {}
+	if cap(in) > 0 {
+		closed = pool.trackBuffer(true, func() int { return len(in) })
+	}
+	out, errc := PipeErr(pool, in, procs, policy)
+
+	ctx := pool.Context()
+	go func() {
+		defer close(in)
+		split(ctx, p, in)
+	}()
+
+	result := merge(ctx, p, out)
+
+	if ctx.Err() != nil {
+		// The merge may have been interrupted: discard the remaining childs
+		for range out { //nolint:revive
+		}
+	}
+
+	// confirm that all elements in out channel where consumed
+	var leakErr error
+	if val, ok := <-out; ok {
+		if leak == nil {
+			pool.log(slog.LevelError, "merge leaked childs", itemKey(p), slog.String("child", fmt.Sprintf("%T", val)), slog.String("result", fmt.Sprintf("%T", result)))
+			panic(fmt.Sprintf("invalid dispatcher merge %T into %T, leaked goroutine", val, result))
+		}
+		leftovers := append([]ChildOut{val}, lo.ChannelToSlice(out)...)
+		pool.log(slog.LevelWarn, "merge leaked childs", itemKey(p), slog.String("child", fmt.Sprintf("%T", val)), slog.Int("leftover", len(leftovers)))
+		leakErr = leak(p, leftovers)
+	}
+
+	// The pipe is over: if it stopped early (cancellation or failed submission), unblock the split
+	go func() {
+		for range in { //nolint:revive
+		}
+		closed() // closed and drained
+	}()
+
+	return result, errors.Join(<-errc, leakErr)
+}
+
+// RunErr executes an ErrProcess on a channel and wait until the input channel is closed and the process is terminated.
+// It returns the aggregation of the errors returned by the policy (CollectErrors if nil), and the stalls detected meanwhile by a watchdog without callback.
+func RunErr[T any](pool *Pools, in <-chan T, proc ErrProcess[T], policy ErrorPolicy[T]) error {
+	stalls := pool.stallWatchdog().reported()
+	out, errc := PipeErr(pool, in, proc, policy)
+	// nolint:revive
+	for range out {
+		// Nothing to do, we just loop until out is closed
+	}
+	return errors.Join(<-errc, pool.stallWatchdog().stallsSince(stalls))
+}
+
+// RunAllErr is a convenient function to run a list of ErrProcess, in order.
+func RunAllErr[T any](pool *Pools, in <-chan T, procs []ErrProcess[T], policy ErrorPolicy[T]) error {
+	return RunErr(pool, in, LinkErr(procs...), policy)
+}
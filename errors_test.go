@@ -0,0 +1,140 @@
+package pipe_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+var errOdd = errors.New("odd value")
+
+// failOdd defines a simple ErrProcess, which fails on odd values.
+func failOdd(_ *pipe.Pools, i int) (int, error) {
+	if i%2 == 1 {
+		return i, errOdd
+	}
+	return i, nil
+}
+
+func TestErrors(t *testing.T) {
+	t.Run("pipe_err_collect", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2)
+		in := lo.SliceToChannel(0, lo.Range(4))
+
+		// Act
+		out, errc := pipe.PipeErr(pool, in, failOdd, nil)
+
+		// Assert
+		td.CmpBag(t, lo.ChannelToSlice(out), []any{0, 2})
+		err := <-errc
+		td.CmpErrorIs(t, err, errOdd)
+		var itemErr *pipe.ItemError[int]
+		td.CmpTrue(t, errors.As(err, &itemErr))
+		td.CmpContains(t, err.Error(), "int item failed: odd value")
+	})
+
+	t.Run("pipe_err_drop", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2)
+		in := lo.SliceToChannel(0, lo.Range(4))
+
+		// Act
+		out, errc := pipe.PipeErr(pool, in, failOdd, pipe.DropErrors[int]())
+
+		// Assert
+		td.CmpBag(t, lo.ChannelToSlice(out), []any{0, 2})
+		td.CmpNoError(t, <-errc)
+	})
+
+	t.Run("run_err_route", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2)
+		in := lo.SliceToChannel(0, lo.Range(4))
+		failed := make(chan *pipe.ItemError[int], 4)
+
+		// Act
+		err := pipe.RunErr(pool, in, failOdd, pipe.RouteErrors(failed))
+
+		// Assert
+		td.CmpNoError(t, err)
+		close(failed)
+		td.CmpBag(t, lo.Map(lo.ChannelToSlice(failed), func(e *pipe.ItemError[int], _ int) int { return e.Item }), []any{1, 3})
+	})
+
+	t.Run("link_err_stops_on_first_error", func(t *testing.T) {
+		// Arrange
+		called := false
+		proc := pipe.LinkErr(failOdd, func(_ *pipe.Pools, i int) (int, error) {
+			called = true
+			return i, nil
+		})
+
+		// Act
+		_, err := proc(nil, 1)
+
+		// Assert
+		td.CmpErrorIs(t, err, errOdd)
+		td.CmpFalse(t, called, "Chain should stop on first error")
+	})
+
+	t.Run("wrap_err_childs", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1, 2)
+		in := lo.SliceToChannel(0, []int{4})
+		var merged []int
+		dispatcher, _ := pipe.NewDispatch(func(parent int, in chan<- int) {
+			for i := 0; i < parent; i++ {
+				in <- i
+			}
+		}, func(parent int, out <-chan int) int {
+			merged = lo.ChannelToSlice(out)
+			return parent
+		})
+
+		// Act
+		err := pipe.RunAllErr(pool, in, []pipe.ErrProcess[int]{pipe.WrapErr(failOdd, dispatcher, nil)}, nil)
+
+		// Assert
+		td.CmpErrorIs(t, err, errOdd)
+		td.CmpBag(t, merged, []any{0, 2})
+	})
+
+	t.Run("wrap_err_drop_childs", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1, 2)
+		in := lo.SliceToChannel(0, []int{4})
+		dispatcher, _ := pipe.NewDispatch(func(parent int, in chan<- int) {
+			for i := 0; i < parent; i++ {
+				in <- i
+			}
+		}, func(_ int, out <-chan int) int {
+			return len(lo.ChannelToSlice(out))
+		})
+		var result int
+
+		// Act
+		err := pipe.RunErr(pool, in, pipe.LinkErr(
+			pipe.WrapErr(failOdd, dispatcher, pipe.DropErrors[int]()),
+			pipe.AsErrProcess(func(_ *pipe.Pools, i int) int { result = i; return i }),
+		), nil)
+
+		// Assert
+		td.CmpNoError(t, err)
+		td.Cmp(t, result, 2)
+	})
+
+	t.Run("wrap_err_invalid_dispatcher", func(t *testing.T) {
+		// Arrange
+		var dispatcher pipe.Dispatch[int, int]
+
+		// Act
+		_, err := pipe.WrapErr(failOdd, dispatcher, nil)(nil, 1)
+
+		// Assert
+		td.CmpErrorIs(t, err, pipe.ErrInvalidDispatcher)
+	})
+}
@@ -145,8 +145,8 @@ func NewEngineBuilder() *JobBuilder {
 }
 
 // Run runs the engine... VROOOOOOOOOOOOOOOOOOOOOOOMMMMMMM !!!.
-func (e *Engine) Run(in <-chan Job) {
-	pipe.Run(e.pool, in, e.proc)
+func (e *Engine) Run(in <-chan Job) error {
+	return pipe.Run(e.pool, in, e.proc)
 }
 
 func ExampleEngine() {
@@ -174,7 +174,9 @@ func ExampleEngine() {
 		{childCount: 1},
 		{childCount: 2},
 	}
-	engine.Run(lo.SliceToChannel(0, jobs)) // Push 2 job, will output two lines
+	if err := engine.Run(lo.SliceToChannel(0, jobs)); err != nil { // Push 2 job, will output two lines
+		fmt.Println(err)
+	}
 
 	// Output:
 	// >Job>>Job>[Job/SubJob]>SubJob>>SubJob>[SubJob\Job]>Job>
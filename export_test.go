@@ -9,3 +9,19 @@ func (p *Pools) Pools() []*ants.Pool {
 	}
 	return p.pools
 }
+
+// TrackedBuffers returns the number of buffered channels registered at a depth.
+func (p *Pools) TrackedBuffers(depth int) int {
+	stats := p.config.level(depth)
+	stats.mutex.Lock()
+	defer stats.mutex.Unlock()
+	return len(stats.buffers) + len(stats.draining)
+}
+
+// KeptStalls returns the number of stalls kept by the watchdog for the running Run functions.
+func (p *Pools) KeptStalls() int {
+	w := p.stallWatchdog()
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return len(w.stalls)
+}
@@ -0,0 +1,30 @@
+package pipe
+
+// Filter is like Pipe, but keeps the inputs for which keep returns true, instead of transforming them.
+// keep runs in the current pool and receives the pools of the next depth, like the process of Pipe.
+func Filter[T any](dp *Pools, in <-chan T, keep func(*Pools, T) bool) <-chan T {
+	return FlatMap(dp, in, func(dp *Pools, t T, out chan<- T) {
+		if keep(dp, t) {
+			out <- t
+		}
+	})
+}
+
+// FlatMap is like Pipe, but each input is expanded into any number of outputs, sent to out by expand.
+// expand runs in the current pool and receives the pools of the next depth, like the process of Pipe.
+//
+// The output channel is closed once all the expansions are done, so a streaming pipeline doesn't have to merge the outputs into a parent.
+// Like Pipe, FlatMap stops dispatching when a task submission fails with the SubmitFail policy: the failure is only reported to the logger and the observers.
+func FlatMap[IN, OUT any](dp *Pools, in <-chan IN, expand func(dp *Pools, value IN, out chan<- OUT)) <-chan OUT {
+	out := make(chan OUT)
+	expanded, _ := pipeErr(dp, in, func(dp *Pools, value IN) (struct{}, error) {
+		expand(dp, value, out)
+		return struct{}{}, nil
+	}, nil, false)
+	go func() {
+		for range expanded { //nolint:revive
+		}
+		close(out)
+	}()
+	return out
+}
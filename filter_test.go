@@ -0,0 +1,70 @@
+package pipe_test
+
+import (
+	"testing"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+func TestFilter(t *testing.T) {
+	t.Run("keep_even", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 3)
+		in := lo.SliceToChannel(0, lo.Range(10))
+
+		// Act
+		out := pipe.Filter(pool, in, func(_ *pipe.Pools, i int) bool { return i%2 == 0 })
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), td.Bag(0, 2, 4, 6, 8))
+	})
+
+	t.Run("nil_pool", func(t *testing.T) {
+		// Arrange
+		in := lo.SliceToChannel(0, lo.Range(5))
+
+		// Act
+		out := pipe.Filter(nil, in, func(_ *pipe.Pools, i int) bool { return i > 2 })
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), []int{3, 4})
+	})
+}
+
+func TestFlatMap(t *testing.T) {
+	t.Run("expand", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2, 3)
+		in := lo.SliceToChannel(0, []int{1, 2, 3})
+
+		// Act
+		out := pipe.FlatMap(pool, in, func(dp *pipe.Pools, i int, out chan<- string) {
+			td.Cmp(t, dp.Sizes(), []int{3}, "Expansions receive the next depth")
+			for j := 0; j < i; j++ {
+				out <- string(rune('a' + i))
+			}
+		})
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), td.Bag("b", "c", "c", "d", "d", "d"))
+	})
+
+	t.Run("streaming_childs", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2, 2)
+		in := lo.SliceToChannel(0, []int{2, 3})
+
+		// Act
+		out := pipe.FlatMap(pool, in, func(dp *pipe.Pools, i int, out chan<- int) {
+			childs := pipe.Pipe(dp, lo.SliceToChannel(0, lo.Range(i)), func(_ *pipe.Pools, j int) int { return i*10 + j })
+			for child := range childs {
+				out <- child
+			}
+		})
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), td.Bag(20, 21, 30, 31, 32))
+	})
+}
@@ -0,0 +1,85 @@
+package pipe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Join is the merge of a fork branch. It consumes the childs of the branch, concurrently with the other branches,
+// and returns the contribution of the branch to the parent, applied once all the branches are over.
+type Join[Parent, Child any] func(parent Parent, out <-chan Child) func(Parent) Parent
+
+// ForkBranch is a branch of a Fork, whose childs have their own type. See NewForkBranch.
+type ForkBranch[Parent any] interface {
+	// fork splits the parent, processes the childs in pool, and returns the contribution of the branch.
+	fork(pool *Pools, parent Parent) (func(Parent) Parent, error)
+}
+
+type forkBranch[Parent, Child any] struct {
+	split Split[Parent, Child]
+	proc  PoolProcess[Child]
+	join  Join[Parent, Child]
+}
+
+// NewForkBranch creates a branch of a Fork: the parent is split into childs, which are processed by proc, then joined into a contribution to the parent.
+func NewForkBranch[Parent, Child any](split Split[Parent, Child], proc PoolProcess[Child], join Join[Parent, Child]) ForkBranch[Parent] {
+	return forkBranch[Parent, Child]{split: split, proc: proc, join: join}
+}
+
+func (b forkBranch[Parent, Child]) fork(pool *Pools, parent Parent) (func(Parent) Parent, error) {
+	if b.split == nil || b.proc == nil || b.join == nil {
+		var c Child
+		return nil, fmt.Errorf("%w: fork branch from %T to %T (nil fields method: %+v)", ErrInvalidDispatcher, parent, c, b)
+	}
+	return wrap(pool, parent,
+		func(_ context.Context, parent Parent, in chan<- Child) { b.split(parent, in) },
+		func(pool *Pools, c Child) (Child, error) { return b.proc(pool, c), nil },
+		nil,
+		func(_ context.Context, parent Parent, out <-chan Child) func(Parent) Parent {
+			return b.join(parent, out)
+		},
+		nil,
+	)
+}
+
+// Fork creates a PoolProcess parent which fans out into several kinds of childs: each branch splits the parent into its own child type,
+// processed by its own process. The childs of all the branches share the next depth of the pools.
+// Once all the branches are over, their contributions are applied to the parent, in the order of the branches.
+//
+// Like Wrap, Fork panics on an invalid branch, a failed submission or a panic of a child.
+func Fork[Parent any](branches ...ForkBranch[Parent]) PoolProcess[Parent] {
+	return func(pool *Pools, p Parent) Parent {
+		contributions := make([]func(Parent) Parent, len(branches))
+		errs := make([]error, len(branches))
+		panics := make([]any, len(branches))
+		var wg sync.WaitGroup
+		for i, branch := range branches {
+			wg.Add(1)
+			go func(i int, branch ForkBranch[Parent]) {
+				defer wg.Done()
+				defer func() { panics[i] = recover() }() // panics again in the parent routine
+				contributions[i], errs[i] = branch.fork(pool, p)
+			}(i, branch)
+		}
+		wg.Wait()
+		for _, v := range panics {
+			if v != nil {
+				panic(v)
+			}
+		}
+		err := errors.Join(errs...)
+		if errors.Is(err, ErrInvalidDispatcher) {
+			panic(err)
+		}
+		panicOnSubmitError(err)
+		panicOnChildPanic(err)
+		for _, contribute := range contributions {
+			if contribute != nil {
+				p = contribute(p)
+			}
+		}
+		return p
+	}
+}
@@ -0,0 +1,94 @@
+package pipe_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+func TestFork(t *testing.T) {
+	type document struct {
+		images []string
+		blocks []string
+		pixels int
+		words  int
+	}
+	images := pipe.NewForkBranch(func(d document, in chan<- string) {
+		for _, image := range d.images {
+			in <- image
+		}
+	}, func(pool *pipe.Pools, image string) string {
+		td.Cmp(t, pool.Sizes(), []int{}, "Childs run in the next depth")
+		return strings.ToUpper(image)
+	}, func(_ document, out <-chan string) func(document) document {
+		pixels := len(lo.ChannelToSlice(out)) * 100
+		return func(d document) document {
+			d.pixels = pixels
+			return d
+		}
+	})
+	type block struct {
+		text  string
+		words int
+	}
+	blocks := pipe.NewForkBranch(func(d document, in chan<- block) {
+		for _, text := range d.blocks {
+			in <- block{text: text}
+		}
+	}, func(_ *pipe.Pools, b block) block {
+		b.words = len(strings.Fields(b.text))
+		return b
+	}, func(_ document, out <-chan block) func(document) document {
+		words := lo.SumBy(lo.ChannelToSlice(out), func(b block) int { return b.words })
+		return func(d document) document {
+			d.words = words
+			return d
+		}
+	})
+
+	t.Run("join", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1, 2)
+		in := lo.SliceToChannel(0, []document{{images: []string{"a.png", "b.png"}, blocks: []string{"hello world", "fork join"}}})
+
+		// Act
+		out := pipe.Pipe(pool, in, pipe.Fork(images, blocks))
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), []document{{images: []string{"a.png", "b.png"}, blocks: []string{"hello world", "fork join"}, pixels: 200, words: 4}})
+	})
+
+	t.Run("invalid_branch", func(t *testing.T) {
+		// Arrange
+		invalid := pipe.NewForkBranch[document, int](nil, nil, nil)
+
+		// Act & Assert
+		td.CmpPanic(t, func() { pipe.Fork(images, invalid)(nil, document{}) },
+			td.Smuggle(func(err error) error { return err }, td.ErrorIs(pipe.ErrInvalidDispatcher)))
+	})
+
+	t.Run("child_panic", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1, 2)
+		failing := pipe.NewForkBranch(func(_ document, in chan<- int) {
+			in <- 1
+		}, func(*pipe.Pools, int) int {
+			panic(errOdd)
+		}, func(_ document, out <-chan int) func(document) document {
+			_ = lo.ChannelToSlice(out)
+			return nil
+		})
+
+		// Act
+		err := pipe.RunErr(pool, lo.SliceToChannel(0, []document{{}}), pipe.AsErrProcess(pipe.Fork(blocks, failing)), nil)
+
+		// Assert
+		var panicErr *pipe.PanicError
+		td.Require(t).True(errors.As(err, &panicErr))
+		td.CmpErrorIs(t, err, errOdd)
+	})
+}
@@ -0,0 +1,55 @@
+package pipe
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrMergeLeak is the error of a merge which returned without consuming all its childs.
+var ErrMergeLeak = errors.New("merge leaked childs")
+
+// MergeLeakError reports the childs left over by the merge of a parent. It matches ErrMergeLeak.
+type MergeLeakError struct {
+	Parent   any
+	Leftover int
+}
+
+// Error implements the error interface.
+func (e *MergeLeakError) Error() string {
+	return fmt.Sprintf("%v: %d childs of %T left over", ErrMergeLeak, e.Leftover, e.Parent)
+}
+
+// Unwrap returns ErrMergeLeak.
+func (e *MergeLeakError) Unwrap() error {
+	return ErrMergeLeak
+}
+
+// LeakPolicy decides what happens to the childs left over by a merge, once they are drained. A returned error fails the parent.
+type LeakPolicy[Parent, Child any] func(parent Parent, leftovers []Child) error
+
+// DiscardLeaks is a LeakPolicy which silently discards the leftover childs.
+func DiscardLeaks[Parent, Child any]() LeakPolicy[Parent, Child] {
+	return func(Parent, []Child) error { return nil }
+}
+
+// HandleLeaks is a LeakPolicy which passes the leftover childs to callback.
+func HandleLeaks[Parent, Child any](callback func(parent Parent, leftovers []Child)) LeakPolicy[Parent, Child] {
+	return func(parent Parent, leftovers []Child) error {
+		callback(parent, leftovers)
+		return nil
+	}
+}
+
+// FailOnLeak is a LeakPolicy which fails the parent with a *MergeLeakError.
+func FailOnLeak[Parent, Child any]() LeakPolicy[Parent, Child] {
+	return func(parent Parent, leftovers []Child) error {
+		return &MergeLeakError{Parent: parent, Leftover: len(leftovers)}
+	}
+}
+
+// WithLeakPolicy returns a copy of the dispatch whose merge may leave childs over: instead of panicking, the remaining childs are drained
+// and handed to policy, so the pipeline keeps running. Since Wrap can't fail, the error of the policy is only logged (see WithLogger), use WrapErr to get it.
+func (d Dispatch[Parent, Child]) WithLeakPolicy(policy LeakPolicy[Parent, Child]) Dispatch[Parent, Child] {
+	d.leak = policy
+	return d
+}
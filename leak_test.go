@@ -0,0 +1,88 @@
+package pipe_test
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+func TestLeakPolicy(t *testing.T) {
+	// leaky merges only the first child of a parent, leaving the others over
+	leaky, err := pipe.NewDispatch(func(parent int, in chan<- int) {
+		for i := 0; i < parent; i++ {
+			in <- i
+		}
+	}, func(parent int, out <-chan int) int {
+		return parent*10 + <-out
+	})
+	td.Require(t).CmpNoError(err)
+
+	t.Run("discard", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2, 2)
+		in := lo.SliceToChannel(0, []int{2, 3})
+
+		// Act
+		out := pipe.Pipe(pool, in, pipe.Wrap(identity[int], leaky.WithLeakPolicy(pipe.DiscardLeaks[int, int]())))
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), td.Bag(td.Between(20, 21), td.Between(30, 32)))
+	})
+
+	t.Run("handle", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2, 2)
+		var mutex sync.Mutex
+		leftovers := map[int]int{}
+		policy := pipe.HandleLeaks(func(parent int, childs []int) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			leftovers[parent] = len(childs)
+		})
+
+		// Act
+		err := pipe.Run(pool, lo.SliceToChannel(0, []int{2, 3}), pipe.Wrap(identity[int], leaky.WithLeakPolicy(policy)))
+
+		// Assert
+		td.CmpNoError(t, err)
+		td.Cmp(t, leftovers, map[int]int{2: 1, 3: 2})
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2, 2)
+		in := lo.SliceToChannel(0, []int{1, 3})
+
+		// Act
+		out, errc := pipe.PipeErr(pool, in, pipe.WrapErr(pipe.AsErrProcess(identity[int]), leaky.WithLeakPolicy(pipe.FailOnLeak[int, int]()), nil), nil)
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), []int{10})
+		err := <-errc
+		td.CmpErrorIs(t, err, pipe.ErrMergeLeak)
+		var leakErr *pipe.MergeLeakError
+		td.Require(t).True(errors.As(err, &leakErr))
+		td.Cmp(t, leakErr, &pipe.MergeLeakError{Parent: 3, Leftover: 2})
+	})
+
+	t.Run("fail_without_error", func(t *testing.T) {
+		// Arrange
+		var logs logRecorder
+		pool := InitPoolWithConfig(t, []int{2, 2}, pipe.WithLogger(slog.New(&logs)))
+		in := lo.SliceToChannel(0, []int{3})
+
+		// Act
+		out := pipe.Pipe(pool, in, pipe.Wrap(identity[int], leaky.WithLeakPolicy(pipe.FailOnLeak[int, int]())))
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), td.Bag(td.Between(30, 32)), "Wrap can't fail: the leak is only logged")
+		td.Cmp(t, logs.messages("merge leak policy failed"), []map[string]any{{
+			"msg": "merge leak policy failed", "level": slog.LevelError, "depth": int64(1), "error": &pipe.MergeLeakError{Parent: 3, Leftover: 2},
+		}})
+	})
+}
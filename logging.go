@@ -0,0 +1,78 @@
+package pipe
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ItemKeyer is implemented by the items which can be identified in the events, the spans and the logs.
+type ItemKeyer interface {
+	ItemKey() string
+}
+
+// itemKey returns the key of an item, empty if the item is not an ItemKeyer.
+func itemKey(item any) string {
+	if keyer, ok := item.(ItemKeyer); ok {
+		return keyer.ItemKey()
+	}
+	return ""
+}
+
+// WithLogger sets the logger of the pools. It records the creation and the release of the pools, the submission failures,
+// the recovered panics, the merges which leak childs in Wrap and the slow items (see WithSlowThreshold).
+func WithLogger(logger *slog.Logger) PoolsOption {
+	return func(c *poolsConfig) {
+		c.logger = logger
+	}
+}
+
+// WithSlowThreshold logs a warning for the items whose execution in the pool of a depth takes longer than threshold.
+func WithSlowThreshold(threshold time.Duration) PoolsOption {
+	return func(c *poolsConfig) {
+		c.slowThreshold = threshold
+	}
+}
+
+// attrs returns the log attributes of an event.
+func (ev Event) attrs() []slog.Attr {
+	attrs := []slog.Attr{slog.Int("depth", ev.Depth)}
+	if ev.Name != "" {
+		attrs = append(attrs, slog.String("stage", ev.Name))
+	}
+	if ev.Key != "" {
+		attrs = append(attrs, slog.String("item", ev.Key))
+	}
+	return attrs
+}
+
+// log records a message, if the pools have a logger.
+func (c *poolsConfig) log(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	if c == nil || c.logger == nil {
+		return
+	}
+	c.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// log records a message about an item of the current depth, if the pools have a logger.
+func (p *Pools) log(level slog.Level, msg, key string, attrs ...slog.Attr) {
+	if p == nil {
+		return
+	}
+	ev := Event{Depth: p.depth, Name: p.name, Key: key}
+	p.config.log(p.Context(), level, msg, append(ev.attrs(), attrs...)...)
+}
+
+// logTask records the end of a task: its panic with the stack of the worker, or its slowness.
+func (c *poolsConfig) logTask(ctx context.Context, ev Event, stack []byte) {
+	if c == nil || c.logger == nil {
+		return
+	}
+	switch {
+	case stack != nil:
+		c.log(ctx, slog.LevelError, "task panicked", append(ev.attrs(), slog.String("panic", fmt.Sprint(ev.Panic)), slog.String("stack", string(stack)))...)
+	case c.slowThreshold > 0 && ev.Elapsed > c.slowThreshold:
+		c.log(ctx, slog.LevelWarn, "slow item", append(ev.attrs(), slog.Duration("elapsed", ev.Elapsed), slog.Duration("threshold", c.slowThreshold))...)
+	}
+}
@@ -0,0 +1,147 @@
+package pipe_test
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/panjf2000/ants/v2"
+	"github.com/samber/lo"
+)
+
+// logRecorder is a slog.Handler which records the messages with their attributes.
+type logRecorder struct {
+	mutex   sync.Mutex
+	records []map[string]any
+}
+
+func (l *logRecorder) Enabled(context.Context, slog.Level) bool { return true }
+func (l *logRecorder) WithAttrs([]slog.Attr) slog.Handler       { return l }
+func (l *logRecorder) WithGroup(string) slog.Handler            { return l }
+
+func (l *logRecorder) Handle(_ context.Context, r slog.Record) error {
+	record := map[string]any{"msg": r.Message, "level": r.Level}
+	r.Attrs(func(attr slog.Attr) bool {
+		record[attr.Key] = attr.Value.Any()
+		return true
+	})
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.records = append(l.records, record)
+	return nil
+}
+
+// messages returns the records of a message.
+func (l *logRecorder) messages(msg string) []map[string]any {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return lo.Filter(l.records, func(record map[string]any, _ int) bool { return record["msg"] == msg })
+}
+
+// job is an item identified in the logs.
+type job int
+
+func (j job) ItemKey() string {
+	return "job-" + strconv.Itoa(int(j))
+}
+
+func TestLogger(t *testing.T) {
+	t.Run("pools_lifecycle", func(t *testing.T) {
+		// Arrange
+		var logs logRecorder
+
+		// Act
+		pools, err := pipe.NewPoolsWithConfig([]int{2, 0}, pipe.WithLogger(slog.New(&logs)))
+		td.Require(t).CmpNoError(err)
+		pools.Release()
+
+		// Assert
+		td.Cmp(t, logs.messages("pools created"), []map[string]any{{"msg": "pools created", "level": slog.LevelInfo, "sizes": []int{2, 0}}})
+		td.Cmp(t, logs.messages("pools released"), []map[string]any{{"msg": "pools released", "level": slog.LevelInfo, "depth": int64(0), "sizes": []int{2, 0}}})
+	})
+
+	t.Run("slow_item", func(t *testing.T) {
+		// Arrange
+		var logs logRecorder
+		pool := InitPoolWithConfig(t, []int{2}, pipe.WithLogger(slog.New(&logs)), pipe.WithSlowThreshold(5*time.Millisecond))
+		in := lo.SliceToChannel(0, []job{1, 2})
+
+		// Act
+		err := pipe.Run(pool.WithName("jobs"), in, func(_ *pipe.Pools, j job) job {
+			if j == 2 {
+				time.Sleep(10 * time.Millisecond)
+			}
+			return j
+		})
+
+		// Assert
+		td.CmpNoError(t, err)
+		td.Cmp(t, logs.messages("slow item"), []map[string]any{{
+			"msg": "slow item", "level": slog.LevelWarn, "depth": int64(0), "stage": "jobs", "item": "job-2",
+			"elapsed": td.Gte(10 * time.Millisecond), "threshold": 5 * time.Millisecond,
+		}})
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		// Arrange
+		var logs logRecorder
+		panicked := make(chan any, 1)
+		pool := InitPoolWithConfig(t, []int{1}, pipe.WithLogger(slog.New(&logs)),
+			pipe.WithAntsOptions(ants.WithPanicHandler(func(v any) { panicked <- v })))
+
+		// Act
+		out := pipe.Pipe(pool, lo.SliceToChannel(0, []job{1}), func(*pipe.Pools, job) job { panic("boom") })
+
+		// Assert
+		td.CmpEmpty(t, lo.ChannelToSlice(out))
+		td.Cmp(t, <-panicked, "boom")
+		td.Cmp(t, logs.messages("task panicked"), []map[string]any{{
+			"msg": "task panicked", "level": slog.LevelError, "depth": int64(0), "item": "job-1", "panic": "boom",
+			"stack": td.Contains("logging_test.go"),
+		}})
+	})
+
+	t.Run("submission_failure", func(t *testing.T) {
+		// Arrange
+		var logs logRecorder
+		pool := InitPoolWithConfig(t, []int{1}, pipe.WithLogger(slog.New(&logs)))
+		pool.Release()
+
+		// Act
+		err := pipe.RunErr(pool, lo.SliceToChannel(0, []job{1}), pipe.AsErrProcess(identity[job]), nil)
+
+		// Assert
+		td.CmpErrorIs(t, err, ants.ErrPoolClosed)
+		td.Cmp(t, logs.messages("task submission failed"), []map[string]any{{
+			"msg": "task submission failed", "level": slog.LevelWarn, "depth": int64(0), "item": "job-1", "error": td.Isa((*pipe.SubmitError)(nil)),
+		}})
+	})
+
+	t.Run("wrap_leak", func(t *testing.T) {
+		// Arrange
+		var logs logRecorder
+		pool := InitPoolWithConfig(t, []int{0, 0}, pipe.WithLogger(slog.New(&logs)))
+		dispatcher, _ := pipe.NewDispatch(func(_ job, in chan<- int) {
+			in <- 1
+			in <- 2
+		}, func(parent job, out <-chan int) job {
+			<-out
+			return parent
+		})
+
+		// Act
+		td.CmpPanic(t, func() {
+			pipe.Wrap(identity[int], dispatcher)(pool, 3)
+		}, td.Contains("leaked goroutine"))
+
+		// Assert
+		td.Cmp(t, logs.messages("merge leaked childs"), []map[string]any{{
+			"msg": "merge leaked childs", "level": slog.LevelError, "depth": int64(0), "item": "job-3", "child": "int", "result": "pipe_test.job",
+		}})
+	})
+}
@@ -0,0 +1,48 @@
+package pipe
+
+import (
+	"context"
+	"fmt"
+)
+
+// Map defines a pool process which changes the type of its input, like a parsing or an enrichment stage. It can be given to Pipe.
+type Map[IN, OUT any] func(*Pools, IN) OUT
+
+// AsMap decorates a PoolProcess, in order to make it seen as a Map.
+func AsMap[T any](proc PoolProcess[T]) Map[T, T] {
+	return Map[T, T](proc)
+}
+
+// Then chains two Maps, so stages can change the type along the chain: `Then(Then(parse, enrich), AsMap(Link(...)))`.
+func Then[A, B, C any](first Map[A, B], next Map[B, C]) Map[A, C] {
+	return func(pool *Pools, a A) C {
+		return next(pool, first(pool, a))
+	}
+}
+
+// Link appends several PoolProcess to the Map.
+func (m Map[IN, OUT]) Link(procs ...PoolProcess[OUT]) Map[IN, OUT] {
+	return Then(m, AsMap(Link(procs...)))
+}
+
+// WrapMap creates a Map from a parent to another type. The parent is split into childs, which are processed concurrently by procs in the next level pools,
+// then merged into the output. It is the type changing counterpart of Wrap.
+func WrapMap[Parent, Child, ChildOut, Out any](split Split[Parent, Child], procs Map[Child, ChildOut], merge func(parent Parent, out <-chan ChildOut) Out) Map[Parent, Out] {
+	return func(pool *Pools, p Parent) Out {
+		if split == nil || merge == nil {
+			var c Child
+			var o Out
+			panic(fmt.Errorf("%w from %T to %T through %T", ErrInvalidDispatcher, p, o, c))
+		}
+		result, err := wrap(pool, p,
+			func(_ context.Context, parent Parent, in chan<- Child) { split(parent, in) },
+			func(pool *Pools, c Child) (ChildOut, error) { return procs(pool, c), nil },
+			nil,
+			func(_ context.Context, parent Parent, out <-chan ChildOut) Out { return merge(parent, out) },
+			nil,
+		)
+		panicOnSubmitError(err)
+		panicOnChildPanic(err)
+		return result
+	}
+}
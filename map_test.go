@@ -0,0 +1,70 @@
+package pipe_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+func TestMap(t *testing.T) {
+	type Parsed struct {
+		words []string
+	}
+	type Enriched struct {
+		count int
+	}
+	parse := pipe.Map[string, Parsed](func(_ *pipe.Pools, raw string) Parsed {
+		return Parsed{words: strings.Fields(raw)}
+	})
+	enrich := pipe.Map[Parsed, Enriched](func(_ *pipe.Pools, p Parsed) Enriched {
+		return Enriched{count: len(p.words)}
+	})
+
+	t.Run("then_changes_type", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2)
+		in := lo.SliceToChannel(0, []string{"a b", "a b c"})
+		chain := pipe.Then(parse, enrich).Link(func(_ *pipe.Pools, e Enriched) Enriched {
+			e.count *= 10
+			return e
+		})
+
+		// Act
+		out := pipe.Pipe(pool, in, chain)
+
+		// Assert
+		td.CmpBag(t, lo.ChannelToSlice(out), []any{Enriched{count: 20}, Enriched{count: 30}})
+	})
+
+	t.Run("wrap_map_changes_type", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1, 2)
+		in := lo.SliceToChannel(0, []Parsed{{words: []string{"1", "22", "333"}}})
+		length := pipe.Map[string, int](func(_ *pipe.Pools, word string) int { return len(word) })
+		wrapped := pipe.WrapMap(func(p Parsed, in chan<- string) {
+			for _, word := range p.words {
+				in <- word
+			}
+		}, length, func(_ Parsed, out <-chan int) string {
+			return strconv.Itoa(lo.Sum(lo.ChannelToSlice(out)))
+		})
+
+		// Act
+		out := pipe.Pipe(pool, in, pipe.Then(wrapped, pipe.AsMap(func(_ *pipe.Pools, s string) string { return "total: " + s })))
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), []string{"total: 6"})
+	})
+
+	t.Run("wrap_map_invalid", func(t *testing.T) {
+		// Arrange
+		wrapped := pipe.WrapMap[string, string, string, string](nil, nil, nil)
+
+		// Act & Assert
+		td.CmpPanic(t, func() { wrapped(nil, "") }, td.Smuggle(func(err error) error { return err }, td.ErrorIs(pipe.ErrInvalidDispatcher)))
+	})
+}
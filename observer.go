@@ -0,0 +1,114 @@
+package pipe
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"time"
+)
+
+// Event describes the life of a task in the pool of a depth.
+type Event struct {
+	Depth   int
+	Name    string        // name of the stage which submitted the task, see Named
+	Key     string        // key of the item, if it is an ItemKeyer
+	Wait    time.Duration // time spent waiting for a worker, since the submission
+	Elapsed time.Duration // execution time
+	Err     error         // error of the item for Finish, its *Deferred for Defer, submission error for Reject
+	Panic   any           // recovered value for Panic
+}
+
+// Observer receives the events of the tasks submitted to the pools. Its methods are called concurrently by the workers, so they should be fast.
+type Observer interface {
+	// Submit is called before a task is submitted to a pool.
+	Submit(Event)
+	// Reject is called when the submission failed, according to the SubmitPolicy of the depth.
+	Reject(Event)
+	// Start is called when a worker starts the task, with the queue wait time.
+	Start(Event)
+	// Finish is called when the task is done, with its execution time and the error of its item.
+	Finish(Event)
+	// Defer is called instead of Finish when the task is done but not its item, deferred like a failed attempt of a Retry: it is submitted again later.
+	Defer(Event)
+	// Panic is called when the task panics, before the panic goes on.
+	Panic(Event)
+}
+
+// WithObserver adds an observer of the tasks of every depth.
+func WithObserver(observer Observer) PoolsOption {
+	return func(c *poolsConfig) {
+		c.observers = append(c.observers, observer)
+	}
+}
+
+// notify calls the method of the observers.
+func (c *poolsConfig) notify(method func(Observer, Event), ev Event) {
+	if c == nil {
+		return
+	}
+	for _, observer := range c.observers {
+		method(observer, ev)
+	}
+}
+
+// run runs a task submitted to the pool of a depth, keeping the stats, notifying the observers, ending the span and logging the task.
+func (c *poolsConfig) run(ctx context.Context, stats *levelStats, ev Event, submitted time.Time, span *Span, task func() error) {
+	start := time.Now()
+	ev.Wait = start.Sub(submitted)
+	stats.started()
+	c.notify(Observer.Start, ev)
+	finished := false
+	defer func() {
+		ev.Elapsed = time.Since(start)
+		c.done(stats)
+		if !finished {
+			ev.Panic = recover()
+			c.endSpan(span, ev)
+			c.notify(Observer.Panic, ev)
+			c.logTask(ctx, ev, debug.Stack())
+			panic(ev.Panic)
+		}
+		c.endSpan(span, ev)
+		if panicErr, ok := ev.Err.(*PanicError); ok { // recovered by the task
+			ev.Panic = panicErr.Value
+			c.notify(Observer.Panic, ev)
+			c.logTask(ctx, ev, panicErr.Stack)
+			return
+		}
+		var later *Deferred
+		if errors.As(ev.Err, &later) { // the item is not over
+			c.notify(Observer.Defer, ev)
+			c.logTask(ctx, ev, nil)
+			return
+		}
+		c.notify(Observer.Finish, ev)
+		c.logTask(ctx, ev, nil)
+	}()
+	ev.Err = task()
+	finished = true
+}
+
+// Name returns the name of the stage, given to the observers.
+func (p *Pools) Name() string {
+	if p == nil {
+		return ""
+	}
+	return p.name
+}
+
+// WithName returns a shallow copy of the pools, whose tasks are named for the observers.
+func (p *Pools) WithName(name string) *Pools {
+	if p == nil {
+		return &Pools{name: name}
+	}
+	result := *p
+	result.name = name
+	return &result
+}
+
+// Named decorates a PoolProcess, so the tasks it submits, like the childs of a Wrap, are named for the observers.
+func Named[T any](name string, proc PoolProcess[T]) PoolProcess[T] {
+	return func(pool *Pools, t T) T {
+		return proc(pool.WithName(name), t)
+	}
+}
@@ -0,0 +1,115 @@
+package pipe_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/panjf2000/ants/v2"
+	"github.com/samber/lo"
+)
+
+// recorder is an Observer which records the events by kind.
+type recorder struct {
+	mutex  sync.Mutex
+	events map[string][]pipe.Event
+}
+
+func (r *recorder) record(kind string, ev pipe.Event) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.events == nil {
+		r.events = map[string][]pipe.Event{}
+	}
+	r.events[kind] = append(r.events[kind], ev)
+}
+
+func (r *recorder) Submit(ev pipe.Event) { r.record("submit", ev) }
+func (r *recorder) Reject(ev pipe.Event) { r.record("reject", ev) }
+func (r *recorder) Start(ev pipe.Event)  { r.record("start", ev) }
+func (r *recorder) Finish(ev pipe.Event) { r.record("finish", ev) }
+func (r *recorder) Defer(ev pipe.Event)  { r.record("defer", ev) }
+func (r *recorder) Panic(ev pipe.Event)  { r.record("panic", ev) }
+
+// names returns the depth and name of the events of a kind.
+func (r *recorder) names(kind string) []any {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return lo.Map(r.events[kind], func(ev pipe.Event, _ int) any {
+		return lo.T2(ev.Depth, ev.Name)
+	})
+}
+
+func TestObserver(t *testing.T) {
+	t.Run("named_wrap_events", func(t *testing.T) {
+		// Arrange
+		var rec recorder
+		pool := InitPoolWithConfig(t, []int{1, 2}, pipe.WithObserver(&rec))
+		in := lo.SliceToChannel(0, []int{2})
+		dispatcher, _ := pipe.NewDispatch(func(parent int, in chan<- int) {
+			for i := 0; i < parent; i++ {
+				in <- i
+			}
+		}, func(parent int, out <-chan int) int {
+			_ = lo.ChannelToSlice(out)
+			return parent
+		})
+
+		// Act
+		err := pipe.Run(pool.WithName("jobs"), in, pipe.Named("subjobs", pipe.Wrap(identity[int], dispatcher)))
+
+		// Assert
+		td.CmpNoError(t, err)
+		expected := []any{lo.T2(0, "jobs"), lo.T2(1, "subjobs"), lo.T2(1, "subjobs")}
+		td.CmpBag(t, rec.names("submit"), expected)
+		td.CmpBag(t, rec.names("start"), expected)
+		td.CmpBag(t, rec.names("finish"), expected)
+		td.CmpEmpty(t, rec.names("panic"))
+	})
+
+	t.Run("finish_error", func(t *testing.T) {
+		// Arrange
+		var rec recorder
+		pool := InitPoolWithConfig(t, []int{1}, pipe.WithObserver(&rec))
+
+		// Act
+		_ = pipe.RunErr(pool, lo.SliceToChannel(0, []int{1}), failOdd, nil)
+
+		// Assert
+		td.Cmp(t, rec.events["finish"], td.Bag(td.SStruct(pipe.Event{Err: errOdd}, td.StructFields{"Wait": td.Ignore(), "Elapsed": td.Ignore()})))
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		// Arrange
+		var rec recorder
+		panicked := make(chan any, 1)
+		pool := InitPoolWithConfig(t, []int{1}, pipe.WithObserver(&rec),
+			pipe.WithAntsOptions(ants.WithPanicHandler(func(v any) { panicked <- v })))
+
+		// Act
+		out := pipe.Pipe(pool, lo.SliceToChannel(0, []int{1}), func(*pipe.Pools, int) int { panic("boom") })
+
+		// Assert
+		td.CmpEmpty(t, lo.ChannelToSlice(out))
+		td.Cmp(t, <-panicked, "boom", "Panic goes on after the observers")
+		td.Cmp(t, lo.Map(rec.events["panic"], func(ev pipe.Event, _ int) any { return ev.Panic }), []any{"boom"})
+		td.CmpEmpty(t, rec.names("finish"))
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		// Arrange
+		var rec recorder
+		pool := InitPoolWithConfig(t, []int{1}, pipe.WithObserver(&rec))
+		pool.Release()
+
+		// Act
+		err := pipe.RunErr(pool, lo.SliceToChannel(0, []int{1}), failOdd, nil)
+
+		// Assert
+		td.CmpErrorIs(t, err, ants.ErrPoolClosed)
+		td.Cmp(t, len(rec.events["reject"]), 1)
+		td.CmpTrue(t, errors.Is(rec.events["reject"][0].Err, ants.ErrPoolClosed))
+	})
+}
@@ -0,0 +1,67 @@
+package pipe
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/panjf2000/ants/v2"
+)
+
+// PoolsOption configures the Pools built by NewPoolsWithConfig.
+type PoolsOption func(*poolsConfig)
+
+// poolsConfig holds the configuration shared by all the depths of a Pools.
+type poolsConfig struct {
+	antsOptions    []ants.Option
+	submitPolicies map[int]SubmitPolicy // by depth
+	defaultSubmit  SubmitPolicy
+	watchdog       *watchdog
+	observers      []Observer
+	spanExporter   SpanExporter
+	logger         *slog.Logger
+	slowThreshold  time.Duration
+	buffers        map[int]bufferSizes // by depth
+
+	levels   []*levelStats // by depth
+	lastDone atomic.Int64  // unix nano time of the last task completion
+}
+
+// newPoolsConfig applies opts on an empty configuration.
+func newPoolsConfig(opts ...PoolsOption) *poolsConfig {
+	config := &poolsConfig{submitPolicies: map[int]SubmitPolicy{}}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return config
+}
+
+// WithAntsOptions adds options to all the underlying ants pools.
+func WithAntsOptions(opts ...ants.Option) PoolsOption {
+	return func(c *poolsConfig) {
+		c.antsOptions = append(c.antsOptions, opts...)
+	}
+}
+
+// WithSubmitPolicy defines the SubmitPolicy of the given depths, or the default SubmitPolicy if there is no depth.
+func WithSubmitPolicy(policy SubmitPolicy, depths ...int) PoolsOption {
+	return func(c *poolsConfig) {
+		if len(depths) == 0 {
+			c.defaultSubmit = policy
+		}
+		for _, depth := range depths {
+			c.submitPolicies[depth] = policy
+		}
+	}
+}
+
+// submitPolicy returns the SubmitPolicy of a depth.
+func (c *poolsConfig) submitPolicy(depth int) SubmitPolicy {
+	if c == nil {
+		return SubmitPolicy{}
+	}
+	if policy, ok := c.submitPolicies[depth]; ok {
+		return policy
+	}
+	return c.defaultSubmit
+}
@@ -0,0 +1,90 @@
+package pipe
+
+import (
+	"errors"
+	"sync"
+)
+
+// PipeOrdered is like Pipe, but the outputs are sent in the order of the inputs, whatever the size of the pools.
+//
+// The window bounds the number of items between their dispatch and their output: a slow item holds back at most window items, so the memory doesn't grow unbounded.
+// Items dropped by a SubmitDrop policy are skipped, as well as the items whose process panicked, like in Pipe.
+func PipeOrdered[IN, OUT any](dp *Pools, in <-chan IN, do func(*Pools, IN) OUT, window int) <-chan OUT {
+	if window < 1 {
+		window = 1
+	}
+	out := make(chan OUT)
+
+	go func() {
+		var wg sync.WaitGroup
+		var mutex sync.Mutex
+		type result struct {
+			value OUT
+			ok    bool // false if the item was dropped or panicked
+		}
+		pending := map[int]result{}
+		next := 0
+		slots := make(chan struct{}, window)
+		// complete registers the result of an item, then sends the results which are ready, in order
+		complete := func(index int, value OUT, ok bool) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			pending[index] = result{value, ok}
+			for r, found := pending[next]; found; r, found = pending[next] {
+				delete(pending, next)
+				next++
+				if r.ok {
+					out <- r.value
+				}
+				<-slots
+			}
+		}
+
+		ctx := dp.Context()
+	dispatching:
+		for index := 0; ; index++ {
+			select {
+			case <-ctx.Done():
+				break dispatching
+			case slots <- struct{}{}:
+			}
+			var value IN
+			var ok bool
+			select {
+			case <-ctx.Done():
+				break dispatching
+			case value, ok = <-in:
+				if !ok {
+					break dispatching
+				}
+			}
+			itemIndex := index
+			wg.Add(1)
+			err := dp.submit(itemKey(value), func(dp *Pools) error {
+				done := false
+				defer func() {
+					if !done { // do panicked: skip the item, so the next ones are not held back
+						var zero OUT
+						complete(itemIndex, zero, false)
+					}
+				}()
+				result := do(dp, value)
+				done = true
+				complete(itemIndex, result, true)
+				return nil
+			}, wg.Done)
+			if err != nil {
+				var zero OUT
+				complete(itemIndex, zero, false)
+				if !errors.Is(err, errDropped) {
+					break dispatching // the failure was reported by submit
+				}
+			}
+		}
+		// Wait for all submitted task were done, to close out channel
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
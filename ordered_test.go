@@ -0,0 +1,107 @@
+package pipe_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/panjf2000/ants/v2"
+	"github.com/samber/lo"
+)
+
+func TestPipeOrdered(t *testing.T) {
+	// reverse makes the first items the slowest ones
+	reverse := func(count int) func(*pipe.Pools, int) int {
+		return func(_ *pipe.Pools, i int) int {
+			time.Sleep(time.Duration(count-i) * time.Millisecond)
+			return i
+		}
+	}
+
+	t.Run("ordered_pool_size_10", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 10)
+		input := lo.Range(20)
+		in := lo.SliceToChannel(0, input)
+
+		// Act
+		out := pipe.PipeOrdered(pool, in, reverse(len(input)), 5)
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), input)
+	})
+
+	t.Run("ordered_nil_pool", func(t *testing.T) {
+		// Arrange
+		input := lo.Range(10)
+		in := lo.SliceToChannel(0, input)
+
+		// Act
+		out := pipe.PipeOrdered(nil, in, reverse(len(input)), 0)
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), input)
+	})
+
+	t.Run("bounded_window", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 10)
+		in := lo.SliceToChannel(0, lo.Range(20))
+		var running, maxRunning atomic.Int32
+		do := func(_ *pipe.Pools, i int) int {
+			current := running.Add(1)
+			defer running.Add(-1)
+			for {
+				if previous := maxRunning.Load(); current <= previous || maxRunning.CompareAndSwap(previous, current) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			return i
+		}
+
+		// Act
+		out := pipe.PipeOrdered(pool, in, do, 3)
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), lo.Range(20))
+		td.Cmp(t, maxRunning.Load(), td.Lte(int32(3)), "The window bounds the items in flight")
+	})
+
+	t.Run("ordered_skip_panicked", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2)
+		in := lo.SliceToChannel(0, lo.Range(10))
+
+		// Act
+		out := pipe.PipeOrdered(pool, in, func(_ *pipe.Pools, i int) int {
+			if i == 3 {
+				panic("boom")
+			}
+			return i
+		}, 4)
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), []int{0, 1, 2, 4, 5, 6, 7, 8, 9}, "The panicked item doesn't hold back the next ones")
+	})
+
+	t.Run("ordered_skip_dropped", func(t *testing.T) {
+		// Arrange
+		pool := InitPoolWithConfig(t, []int{1},
+			pipe.WithAntsOptions(ants.WithNonblocking(true)),
+			pipe.WithSubmitPolicy(pipe.SubmitDrop(nil)))
+		in := lo.SliceToChannel(0, lo.Range(5))
+
+		// Act
+		out := pipe.PipeOrdered(pool, in, reverse(5), 5)
+
+		// Assert
+		results := lo.ChannelToSlice(out)
+		td.Cmp(t, results, td.Smuggle(func(r []int) bool {
+			return lo.IsSorted(r)
+		}, true), "Remaining items are still in order")
+		td.Cmp(t, len(results), td.Between(1, 5))
+	})
+}
@@ -0,0 +1,61 @@
+package pipe
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+// PanicError is the error of an item whose process panicked. It is recovered by the error aware pipes (PipeErr, WrapErr, RunErr...)
+// and handed to their ErrorPolicy like any returned error.
+type PanicError struct {
+	Value any    // recovered value
+	Stack []byte // stack trace of the panicking goroutine
+	Depth int    // depth of the pool which ran the item
+	Stage string // name of the stage, see Named
+	Item  string // key of the item, if it is an ItemKeyer
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	var where strings.Builder
+	fmt.Fprintf(&where, "depth %d", e.Depth)
+	if e.Stage != "" {
+		fmt.Fprintf(&where, ", stage %q", e.Stage)
+	}
+	if e.Item != "" {
+		fmt.Fprintf(&where, ", item %q", e.Item)
+	}
+	return fmt.Sprintf("panic at %s: %v", where.String(), e.Value)
+}
+
+// Unwrap returns the recovered value if it is an error, like the *PanicError of a child re-panicked by Wrap.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// recovering decorates do, so that its panics are returned as a *PanicError of the depth of dp.
+func recovering[IN, OUT any](dp *Pools, do func(*Pools, IN) (OUT, error)) func(*Pools, IN) (OUT, error) {
+	depth, stage := 0, dp.Name()
+	if dp != nil {
+		depth = dp.depth
+	}
+	return func(pool *Pools, value IN) (result OUT, err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				err = &PanicError{Value: v, Stack: debug.Stack(), Depth: depth, Stage: stage, Item: itemKey(value)}
+			}
+		}()
+		return do(pool, value)
+	}
+}
+
+// panicOnChildPanic lets the processes which can't return errors, like Wrap, panic in the parent routine when a child panicked.
+func panicOnChildPanic(err error) {
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		panic(panicErr)
+	}
+}
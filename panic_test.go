@@ -0,0 +1,117 @@
+package pipe_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+func TestPanicError(t *testing.T) {
+	panicOn2 := func(_ *pipe.Pools, j job) (job, error) {
+		if j == 2 {
+			panic("boom")
+		}
+		return j, nil
+	}
+
+	t.Run("pipe_err", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2)
+		in := lo.SliceToChannel(0, []job{1, 2, 3})
+
+		// Act
+		out, errc := pipe.PipeErr(pool.WithName("jobs"), in, panicOn2, nil)
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), td.Bag(job(1), job(3)), "One bad item doesn't lose the others")
+		err := <-errc
+		var panicErr *pipe.PanicError
+		td.Require(t).True(errors.As(err, &panicErr))
+		td.Cmp(t, panicErr, td.Struct(&pipe.PanicError{Value: "boom", Depth: 0, Stage: "jobs", Item: "job-2"}, td.StructFields{
+			"Stack": td.Smuggle(func(stack []byte) string { return string(stack) }, td.Contains("panic_test.go")),
+		}))
+		td.Cmp(t, err.Error(), `pipe_test.job item failed: panic at depth 0, stage "jobs", item "job-2": boom`)
+	})
+
+	t.Run("wrap_err_child", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1, 2)
+		dispatcher, _ := pipe.NewDispatch(func(parent job, in chan<- job) {
+			in <- parent
+			in <- parent + 1
+		}, func(parent job, out <-chan job) job {
+			_ = lo.ChannelToSlice(out)
+			return parent
+		})
+
+		// Act
+		err := pipe.RunErr(pool, lo.SliceToChannel(0, []job{1}), pipe.WrapErr(panicOn2, dispatcher, nil), nil)
+
+		// Assert
+		var panicErr *pipe.PanicError
+		td.Require(t).True(errors.As(err, &panicErr))
+		td.Cmp(t, panicErr.Depth, 1)
+		td.Cmp(t, panicErr.Item, "job-2")
+	})
+
+	t.Run("wrap_repanic", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1, 2)
+		dispatcher, _ := pipe.NewDispatch(func(parent int, in chan<- int) {
+			in <- parent
+		}, func(parent int, out <-chan int) int {
+			_ = lo.ChannelToSlice(out)
+			return parent
+		})
+		child := func(*pipe.Pools, int) int { panic(errOdd) }
+
+		// Act
+		err := pipe.RunErr(pool, lo.SliceToChannel(0, []int{1}), pipe.AsErrProcess(pipe.Wrap(child, dispatcher)), nil)
+
+		// Assert
+		var panicErr *pipe.PanicError
+		td.Require(t).True(errors.As(err, &panicErr))
+		td.Cmp(t, panicErr.Depth, 0, "Wrap panics in the parent routine")
+		td.Cmp(t, panicErr.Value, td.Isa((*pipe.PanicError)(nil)))
+		td.Cmp(t, panicErr.Value.(*pipe.PanicError).Depth, 1)
+		td.CmpErrorIs(t, err, errOdd)
+	})
+
+	t.Run("run", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1)
+		panicOn2 := func(_ *pipe.Pools, j job) job {
+			if j == 2 {
+				panic("boom")
+			}
+			return j
+		}
+
+		// Act
+		err := pipe.Run(pool, lo.SliceToChannel(0, []job{1, 2}), panicOn2)
+		errCtx := pipe.RunContext(context.Background(), pool, lo.SliceToChannel(0, []job{1, 2}), panicOn2)
+
+		// Assert
+		var panicErr *pipe.PanicError
+		td.Require(t).True(errors.As(err, &panicErr))
+		td.Cmp(t, panicErr.Value, "boom")
+		td.Cmp(t, err.Error(), errCtx.Error(), "Run behaves like RunContext")
+	})
+
+	t.Run("observed", func(t *testing.T) {
+		// Arrange
+		var rec recorder
+		pool := InitPoolWithConfig(t, []int{1}, pipe.WithObserver(&rec))
+
+		// Act
+		_ = pipe.RunErr(pool, lo.SliceToChannel(0, []job{2}), panicOn2, nil)
+
+		// Assert
+		td.Cmp(t, lo.Map(rec.events["panic"], func(ev pipe.Event, _ int) any { return ev.Panic }), []any{"boom"})
+		td.CmpEmpty(t, rec.names("finish"))
+	})
+}
@@ -1,7 +1,9 @@
 package pipe
 
 import (
-	"sync"
+	"context"
+	"log/slog"
+	"time"
 
 	"github.com/panjf2000/ants/v2"
 	"github.com/samber/lo"
@@ -9,11 +11,18 @@ import (
 
 // Pools define a slice of in depth pools.
 type Pools struct {
-	pools []*ants.Pool
+	pools   []*ants.Pool
+	config  *poolsConfig // shared by all the depths
+	depth   int          // depth of pools[0]
+	ctx     context.Context
+	name    string       // name of the stage, given to the observers
+	buffers *bufferSizes // sizes of the channels of the current depth, overriding the configuration
 }
 
 // Release releases all the pools inside the pools.
 func (p *Pools) Release() {
+	p.stallWatchdog().close()
+	p.log(slog.LevelInfo, "pools released", "", slog.Any("sizes", p.Sizes()))
 	for _, p := range p.pools {
 		if p == nil {
 			continue
@@ -22,18 +31,47 @@ func (p *Pools) Release() {
 	}
 }
 
+// Sizes returns the size of the pool of each remaining depth, 0 for a depth which runs in its parent routine.
+func (p *Pools) Sizes() []int {
+	if p == nil {
+		return nil
+	}
+	return lo.Map(p.pools, func(pool *ants.Pool, _ int) int {
+		if pool == nil {
+			return 0
+		}
+		return pool.Cap()
+	})
+}
+
+// capacity returns the number of workers of the current depth, 0 if it runs in its parent routine.
+func (p *Pools) capacity() int {
+	if sizes := p.Sizes(); len(sizes) > 0 {
+		return sizes[0]
+	}
+	return 0
+}
+
 // NewPoolsWithOptions builds a depth pools with the size in parameters. If there is no size, no pools will be created. Submit will not run in parallel.
 //
 // Moreover, a size of 0 means that the task pushed at this level will run in their parent routine (or alike).
 func NewPoolsWithOptions(poolSizes []int, opts ...ants.Option) (*Pools, error) {
+	return NewPoolsWithConfig(poolSizes, WithAntsOptions(opts...))
+}
+
+// NewPoolsWithConfig builds a depth pools like NewPoolsWithOptions, configured by PoolsOption.
+func NewPoolsWithConfig(poolSizes []int, opts ...PoolsOption) (*Pools, error) {
+	config := newPoolsConfig(opts...)
+	config.levels = lo.Times(len(poolSizes), func(i int) *levelStats { return &levelStats{capacity: poolSizes[i]} })
 	var err error
 	result := &Pools{
+		config: config,
 		pools: lo.FilterMap(poolSizes, func(size, _ int) (pool *ants.Pool, ok bool) {
 			if err != nil {
 				return nil, false
 			}
 			if size != 0 { // if size == 0, it will yield a nil pool, which is OK :  related subprocess will be run in parent process
-				pool, err = ants.NewPool(size, opts...)
+				pool, err = ants.NewPool(size, config.antsOptions...)
 			}
 			return pool, err == nil
 		}),
@@ -42,6 +80,8 @@ func NewPoolsWithOptions(poolSizes []int, opts ...ants.Option) (*Pools, error) {
 		result.Release() // release eventually created pools
 		return nil, err
 	}
+	config.watchdog.start(result)
+	config.log(context.Background(), slog.LevelInfo, "pools created", slog.Any("sizes", poolSizes))
 	return result, nil
 }
 
@@ -51,42 +91,64 @@ func NewPools(poolSizes ...int) (*Pools, error) {
 }
 
 // Pipe allows to Pipe a channel in and out in the depth pool. It will execute the task in the current pool and pass the next level pool to the child task.
+//
+// When a task submission fails with the SubmitFail policy, Pipe stops dispatching and closes its output once the submitted tasks are done.
+// Since Pipe can't return errors, the failure is only reported to the logger and the observers. Use PipeErr or Run to get the submission errors.
 func Pipe[IN, OUT any](dp *Pools, in <-chan IN, do func(*Pools, IN) OUT) <-chan OUT {
-	out := make(chan OUT)
-
-	go func() {
-		var wg sync.WaitGroup
-		for dispatch := range in {
-			value := dispatch
-			wg.Add(1)
-			dp.submit(func(dp *Pools) {
-				defer wg.Done()
-				out <- do(dp, value)
-			})
-		}
-		// Wait for all submitted task were done, to close out channel
-		wg.Wait()
-		close(out)
-	}()
-
+	out, _ := pipeErr(dp, in, func(dp *Pools, value IN) (OUT, error) {
+		return do(dp, value), nil
+	}, nil, false)
 	return out
 }
 
+// children returns the pools of the next depth.
+func (p *Pools) children() *Pools {
+	return &Pools{pools: p.pools[1:], config: p.config, depth: p.depth + 1, ctx: p.ctx, name: p.name}
+}
+
 // submit submits a task to the pools. if the remaining pools are empty, it is blocking until the task complete.
-func (p *Pools) submit(f func(*Pools)) {
+// The error returned by the task is the error of its item, it is given to the observers with the key of the item.
+// done is called once the task is over and the observers are notified, or when the task won't run.
+//
+// When the submission fails, the SubmitPolicy of the current depth applies: the returned error is either a *SubmitError or errDropped.
+func (p *Pools) submit(key string, f func(*Pools) error, done func()) error {
 	if p == nil || len(p.pools) == 0 {
-		f(p) // If there is no more available pools or no pool at all, just do it in current routine thread
-		return
+		defer done()
+		var inline *Pools
+		if p != nil {
+			copied := *p // the span of the item is bound to its own copy
+			inline = &copied
+		}
+		p.runInline(inline, f) // If there is no more available pools or no pool at all, just do it in current routine thread
+		return nil
 	}
 	currentPool := p.pools[0]
-	childrenPools := &Pools{pools: p.pools[1:]}
+	childrenPools := p.children()
 	if currentPool == nil {
-		f(childrenPools) // If the current pool is nil, run in the current thread
-		return
+		defer done()
+		p.runInline(childrenPools, f) // If the current pool is nil, run in the current thread
+		return nil
 	}
-	err := p.pools[0].Submit(func() { f(childrenPools) })
-	if err != nil {
-		// FIXME have a proper error handling, even if it shouldn't happens, except for some exotic configuration
-		panic(err)
+	stats := p.config.level(p.depth)
+	ev := Event{Depth: p.depth, Name: p.name, Key: key}
+	submitted := time.Now()
+	stats.waiting.Add(1)
+	p.config.notify(Observer.Submit, ev)
+	span := p.startSpan(childrenPools, submitted)
+	task := func() {
+		defer done()
+		p.config.run(p.Context(), stats, ev, submitted, span, func() error { return f(childrenPools) })
+	}
+	err := currentPool.Submit(task)
+	if err == nil {
+		return nil
+	}
+	if err = p.config.submitPolicy(p.depth).handle(p, currentPool, task, err); err != nil {
+		stats.waiting.Add(-1) // the task won't run
+		ev.Err = err
+		p.config.notify(Observer.Reject, ev)
+		p.config.log(p.Context(), slog.LevelWarn, "task submission failed", append(ev.attrs(), slog.Any("error", err))...)
+		done()
 	}
+	return err
 }
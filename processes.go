@@ -1,8 +1,10 @@
 package pipe
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 
 	"github.com/samber/lo"
 )
@@ -23,13 +25,20 @@ type Merge[Parent, Child any] func(parent Parent, out <-chan Child) Parent
 
 // Dispatch combines Split and Merge since there are linked. Basically, in a program flows we will do : `parent -(Split)-> [childs...] -(Merge)-> parent`.
 type Dispatch[Parent, Child any] struct {
-	split Split[Parent, Child]
-	merge Merge[Parent, Child]
+	split SplitContext[Parent, Child]
+	merge MergeContext[Parent, Child]
+	leak  LeakPolicy[Parent, Child] // nil panics on leaked childs
 }
 
 // NewDispatch creates a Dispatch from Split and Merge functions.
 func NewDispatch[Parent, Child any](split Split[Parent, Child], merge Merge[Parent, Child]) (Dispatch[Parent, Child], error) {
-	result := Dispatch[Parent, Child]{split, merge}
+	var result Dispatch[Parent, Child]
+	if split != nil {
+		result.split = func(_ context.Context, parent Parent, in chan<- Child) { split(parent, in) }
+	}
+	if merge != nil {
+		result.merge = func(_ context.Context, parent Parent, out <-chan Child) Parent { return merge(parent, out) }
+	}
 	return result, result.Validate()
 }
 
@@ -68,45 +77,39 @@ type AnyProcess[T any] interface {
 }
 
 // Wrap creates a PoolProcess parent from a child pool process and a dispatcher. Child PoolProcess will be called concurrently triggered by dispatcher Split function, then merged into through the dispatcher Merge function.
+//
+// Since Wrap can't return errors, a panic of a child panics the parent routine with a *PanicError,
+// and the error of the LeakPolicy of the dispatch is logged.
 func Wrap[Parent, Child any](procs PoolProcess[Child], dispatch Dispatch[Parent, Child]) PoolProcess[Parent] {
 	return func(pool *Pools, p Parent) Parent {
 		if err := dispatch.Validate(); err != nil {
 			panic(err)
 		}
-
-		in := make(chan Child)
-		out := Pipe(pool, in, func(pool *Pools, c Child) Child {
-			return procs(pool, c)
-		})
-
-		go func() {
-			defer close(in)
-			dispatch.split(p, in)
-		}()
-
-		result := dispatch.merge(p, out)
-
-		// confirm that all elements in out channel where consumed
-		if val, ok := <-out; ok {
-			panic(fmt.Sprintf("invalid dispatcher merge %T into %T, leaked goroutine", val, result))
+		leak := dispatch.leak
+		if leak != nil {
+			leak = func(parent Parent, leftovers []Child) error {
+				if err := dispatch.leak(parent, leftovers); err != nil {
+					pool.log(slog.LevelError, "merge leak policy failed", itemKey(parent), slog.Any("error", err))
+				}
+				return nil
+			}
 		}
-
+		// childs never fail, but their submission may, and they may panic
+		result, err := wrap(pool, p, dispatch.split, AsErrProcess(procs), nil, dispatch.merge, leak)
+		panicOnSubmitError(err)
+		panicOnChildPanic(err)
 		return result
 	}
 }
 
 // Run executes a pool process on a channel and wait until the input channel is closed and the process is terminated.
-func Run[T any](pool *Pools, in <-chan T, proc PoolProcess[T]) {
-	out := Pipe(pool, in, func(pool *Pools, t T) T {
-		return proc(pool, t)
-	})
-	// nolint:revive
-	for range out {
-		// Nothing to do, we just loop until out is closed
-	}
+// It returns the failed submissions, see SubmitPolicy, and the context error if the input was not fully consumed.
+// Like RunErr, it recovers the panics of proc: their items fail with a *PanicError, returned with the other failures.
+func Run[T any](pool *Pools, in <-chan T, proc PoolProcess[T]) error {
+	return RunErr(pool, in, AsErrProcess(proc), nil)
 }
 
 // RunAll is a convenient function to run a list of Poolprocess, in order.
-func RunAll[T any](pool *Pools, in <-chan T, procs []PoolProcess[T]) {
-	Run(pool, in, Link(procs...))
+func RunAll[T any](pool *Pools, in <-chan T, procs []PoolProcess[T]) error {
+	return Run(pool, in, Link(procs...))
 }
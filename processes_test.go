@@ -1,13 +1,12 @@
 package pipe_test
 
 import (
-	"sync"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/fogfactory/pipe"
 	"github.com/maxatome/go-testdeep/td"
-	"github.com/panjf2000/ants/v2"
 	"github.com/samber/lo"
 )
 
@@ -27,9 +26,7 @@ func TestProcesses(t *testing.T) {
 
 	t.Run("panic_invalid_dispatcher", func(t *testing.T) {
 		// Arrange
-		var mutex sync.Mutex
-		var panicResult any
-		pool := InitPoolWithOptions(t, []int{1}, ants.WithPanicHandler(func(i interface{}) { mutex.Lock(); defer mutex.Unlock(); panicResult = i }))
+		pool := InitPool(t, 1)
 		input := lo.Range(10)
 		in := lo.SliceToChannel(0, input)
 		mainProc := func(_ *pipe.Pools, i int) int { return i }
@@ -42,12 +39,12 @@ func TestProcesses(t *testing.T) {
 		mainProc = pipe.Wrap(mainProc, dispatcher)
 
 		// Act
-		pipe.Run(pool, in, mainProc)
+		err := pipe.Run(pool, in, mainProc)
 
 		// Assert
-		mutex.Lock()
-		defer mutex.Unlock()
-		td.CmpContains(t, panicResult, "invalid dispatcher merge int into int, leaked goroutine")
+		var panicErr *pipe.PanicError
+		td.Require(t).True(errors.As(err, &panicErr), "The panic is returned by Run")
+		td.CmpContains(t, panicErr.Value, "invalid dispatcher merge int into int, leaked goroutine")
 	})
 
 	t.Run("success_linear_process_no_pool", func(t *testing.T) {
@@ -99,9 +96,10 @@ func TestProcesses(t *testing.T) {
 			collectResult)
 
 		// Act
-		pipe.Run(pool, in, process)
+		err := pipe.Run(pool, in, process)
 
 		// Assert
+		td.CmpNoError(t, err)
 		td.Cmp(t, result, 20)
 	})
 
@@ -144,9 +142,10 @@ func TestProcesses(t *testing.T) {
 			collectResult)
 
 		// Act
-		pipe.Run(pool, in, process)
+		err := pipe.Run(pool, in, process)
 
 		// Assert
+		td.CmpNoError(t, err)
 		td.Cmp(t, result, 21)
 		td.CmpFalse(t, deadlock, "Deadlock detected. Branchs are not runned in several pools")
 	})
@@ -180,9 +179,10 @@ func TestProcesses(t *testing.T) {
 		process := pipe.Wrap(branchProcess, dispatcher)
 
 		// Act
-		pipe.Run(pool, in, process)
+		err := pipe.Run(pool, in, process)
 
 		// Assert
+		td.CmpNoError(t, err)
 		td.CmpTrue(t, deadlock, "No deadlock detected. Branchs are runned in several pools.")
 	})
 
@@ -205,9 +205,10 @@ func TestProcesses(t *testing.T) {
 		})
 
 		// Act
-		pipe.Run(pool, in, process)
+		err := pipe.Run(pool, in, process)
 
 		// Assert
+		td.CmpNoError(t, err)
 		td.CmpFalse(t, deadlock, "Deadlock detected. Mains are not runned in several pools")
 	})
 }
@@ -0,0 +1,161 @@
+/*
+prom exports the pipe metrics in the Prometheus text exposition format, without any dependency on the Prometheus client.
+
+	collector := pipe.NewCollector()
+	pools, _ := pipe.NewPoolsWithConfig([]int{4, 16}, pipe.WithObserver(collector))
+	http.Handle("/metrics", prom.NewExporter(pools, collector))
+*/
+package prom
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fogfactory/pipe"
+)
+
+// ContentType is the content type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Exporter writes the activity of the pools and the metrics of a collector. It is an http.Handler.
+type Exporter struct {
+	pools     *pipe.Pools
+	collector *pipe.Collector
+}
+
+// NewExporter creates an Exporter. pools or collector may be nil, their metrics are then skipped.
+func NewExporter(pools *pipe.Pools, collector *pipe.Collector) *Exporter {
+	return &Exporter{pools: pools, collector: collector}
+}
+
+// ServeHTTP implements http.Handler.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	_, _ = e.WriteTo(w)
+}
+
+// WriteTo writes the metrics to w, implementing io.WriterTo.
+func (e *Exporter) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: bufio.NewWriter(w)}
+	if e.pools != nil {
+		stats := e.pools.Stats()
+		for _, gauge := range []struct {
+			name, help string
+			value      func(pipe.LevelStats) int
+		}{
+			{"pipe_pool_capacity", "Number of workers of the pool of a depth.", func(s pipe.LevelStats) int { return s.Capacity }},
+			{"pipe_pool_running", "Number of running workers of the pool of a depth.", func(s pipe.LevelStats) int { return s.Running }},
+			{"pipe_pool_waiting", "Number of tasks waiting for a worker of the pool of a depth.", func(s pipe.LevelStats) int { return s.Waiting }},
+			{"pipe_buffered_in_items", "Number of childs waiting in the buffered channels towards the pool of a depth.", func(s pipe.LevelStats) int { return s.BufferedIn }},
+			{"pipe_buffered_out_items", "Number of outputs waiting in the buffered channels from the pool of a depth.", func(s pipe.LevelStats) int { return s.BufferedOut }},
+			{"pipe_detached_items", "Number of timed out items of a depth still running outside the pool.", func(s pipe.LevelStats) int { return s.Detached }},
+		} {
+			cw.header(gauge.name, gauge.help, "gauge")
+			for _, s := range stats {
+				cw.sample(gauge.name, labels(s.Depth, ""), float64(gauge.value(s)))
+			}
+		}
+	}
+	if e.collector != nil {
+		metrics := e.collector.Snapshot()
+		for _, counter := range []struct {
+			name, help string
+			value      func(pipe.Metrics) int64
+		}{
+			{"pipe_tasks_submitted_total", "Number of tasks submitted to the pools.", func(m pipe.Metrics) int64 { return m.Submitted }},
+			{"pipe_tasks_rejected_total", "Number of tasks whose submission failed.", func(m pipe.Metrics) int64 { return m.Rejected }},
+			{"pipe_items_processed_total", "Number of processed items.", func(m pipe.Metrics) int64 { return m.Finished }},
+			{"pipe_items_errors_total", "Number of processed items which failed.", func(m pipe.Metrics) int64 { return m.Errors }},
+			{"pipe_items_deferred_total", "Number of times items were deferred, like the failed attempts of a retry.", func(m pipe.Metrics) int64 { return m.Deferred }},
+			{"pipe_tasks_panics_total", "Number of tasks which panicked.", func(m pipe.Metrics) int64 { return m.Panics }},
+		} {
+			cw.header(counter.name, counter.help, "counter")
+			for _, m := range metrics {
+				cw.sample(counter.name, labels(m.Depth, m.Name), float64(counter.value(m)))
+			}
+		}
+		for _, histogram := range []struct {
+			name, help string
+			value      func(pipe.Metrics) pipe.Histogram
+		}{
+			{"pipe_task_wait_seconds", "Time spent by the tasks waiting for a worker.", func(m pipe.Metrics) pipe.Histogram { return m.Wait }},
+			{"pipe_task_duration_seconds", "Execution time of the tasks.", func(m pipe.Metrics) pipe.Histogram { return m.Latency }},
+		} {
+			cw.header(histogram.name, histogram.help, "histogram")
+			for _, m := range metrics {
+				cw.histogram(histogram.name, labels(m.Depth, m.Name), histogram.value(m))
+			}
+		}
+		if breakers := e.collector.Breakers(); len(breakers) > 0 {
+			cw.header("pipe_breaker_state", "State of a circuit breaker: 0 closed, 1 open, 2 half-open.", "gauge")
+			for _, b := range breakers {
+				cw.sample("pipe_breaker_state", []string{`breaker="` + escape(b.Name) + `"`}, float64(b.State))
+			}
+			cw.header("pipe_breaker_opened_total", "Number of times a circuit breaker opened.", "counter")
+			for _, b := range breakers {
+				cw.sample("pipe_breaker_opened_total", []string{`breaker="` + escape(b.Name) + `"`}, float64(b.Opened))
+			}
+		}
+	}
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// labels formats the labels of a depth and an optional stage name.
+func labels(depth int, name string) []string {
+	result := []string{`depth="` + strconv.Itoa(depth) + `"`}
+	if name != "" {
+		result = append(result, `stage="`+escape(name)+`"`)
+	}
+	return result
+}
+
+// escape escapes a label value.
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// countWriter writes the exposition format, keeping the written count and the first error.
+type countWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countWriter) printf(format string, args ...any) {
+	if cw.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(cw.w, format, args...)
+	cw.n += int64(n)
+	cw.err = err
+}
+
+func (cw *countWriter) header(name, help, kind string) {
+	cw.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (cw *countWriter) sample(name string, labels []string, value float64) {
+	cw.printf("%s{%s} %s\n", name, strings.Join(labels, ","), strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (cw *countWriter) histogram(name string, labels []string, h pipe.Histogram) {
+	for i, bucket := range h.Buckets {
+		cw.sample(name+"_bucket", append(labels[:len(labels):len(labels)], `le="`+seconds(bucket)+`"`), float64(h.Counts[i]))
+	}
+	cw.sample(name+"_bucket", append(labels[:len(labels):len(labels)], `le="+Inf"`), float64(h.Count))
+	cw.sample(name+"_sum", labels, h.Sum.Seconds())
+	cw.sample(name+"_count", labels, float64(h.Count))
+}
+
+// seconds formats a duration in seconds.
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'g', -1, 64)
+}
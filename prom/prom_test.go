@@ -0,0 +1,128 @@
+package prom_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fogfactory/pipe"
+	"github.com/fogfactory/pipe/prom"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func TestExporter(t *testing.T) {
+	t.Run("write_metrics", func(t *testing.T) {
+		// Arrange
+		collector := pipe.NewCollector(time.Millisecond, time.Second)
+		collector.Submit(pipe.Event{Depth: 1, Name: `sub"jobs`})
+		collector.Start(pipe.Event{Depth: 1, Name: `sub"jobs`, Wait: 500 * time.Microsecond})
+		collector.Finish(pipe.Event{Depth: 1, Name: `sub"jobs`, Elapsed: 2 * time.Millisecond, Err: errors.New("failed")})
+		pools, err := pipe.NewPools(2, 0)
+		td.Require(t).CmpNoError(err)
+		t.Cleanup(pools.Release)
+		var buf bytes.Buffer
+
+		// Act
+		n, err := prom.NewExporter(pools, collector).WriteTo(&buf)
+
+		// Assert
+		td.CmpNoError(t, err)
+		td.Cmp(t, n, int64(buf.Len()))
+		td.Cmp(t, buf.String(), `# HELP pipe_pool_capacity Number of workers of the pool of a depth.
+# TYPE pipe_pool_capacity gauge
+pipe_pool_capacity{depth="0"} 2
+pipe_pool_capacity{depth="1"} 0
+# HELP pipe_pool_running Number of running workers of the pool of a depth.
+# TYPE pipe_pool_running gauge
+pipe_pool_running{depth="0"} 0
+pipe_pool_running{depth="1"} 0
+# HELP pipe_pool_waiting Number of tasks waiting for a worker of the pool of a depth.
+# TYPE pipe_pool_waiting gauge
+pipe_pool_waiting{depth="0"} 0
+pipe_pool_waiting{depth="1"} 0
+# HELP pipe_buffered_in_items Number of childs waiting in the buffered channels towards the pool of a depth.
+# TYPE pipe_buffered_in_items gauge
+pipe_buffered_in_items{depth="0"} 0
+pipe_buffered_in_items{depth="1"} 0
+# HELP pipe_buffered_out_items Number of outputs waiting in the buffered channels from the pool of a depth.
+# TYPE pipe_buffered_out_items gauge
+pipe_buffered_out_items{depth="0"} 0
+pipe_buffered_out_items{depth="1"} 0
+# HELP pipe_detached_items Number of timed out items of a depth still running outside the pool.
+# TYPE pipe_detached_items gauge
+pipe_detached_items{depth="0"} 0
+pipe_detached_items{depth="1"} 0
+# HELP pipe_tasks_submitted_total Number of tasks submitted to the pools.
+# TYPE pipe_tasks_submitted_total counter
+pipe_tasks_submitted_total{depth="1",stage="sub\"jobs"} 1
+# HELP pipe_tasks_rejected_total Number of tasks whose submission failed.
+# TYPE pipe_tasks_rejected_total counter
+pipe_tasks_rejected_total{depth="1",stage="sub\"jobs"} 0
+# HELP pipe_items_processed_total Number of processed items.
+# TYPE pipe_items_processed_total counter
+pipe_items_processed_total{depth="1",stage="sub\"jobs"} 1
+# HELP pipe_items_errors_total Number of processed items which failed.
+# TYPE pipe_items_errors_total counter
+pipe_items_errors_total{depth="1",stage="sub\"jobs"} 1
+# HELP pipe_items_deferred_total Number of times items were deferred, like the failed attempts of a retry.
+# TYPE pipe_items_deferred_total counter
+pipe_items_deferred_total{depth="1",stage="sub\"jobs"} 0
+# HELP pipe_tasks_panics_total Number of tasks which panicked.
+# TYPE pipe_tasks_panics_total counter
+pipe_tasks_panics_total{depth="1",stage="sub\"jobs"} 0
+# HELP pipe_task_wait_seconds Time spent by the tasks waiting for a worker.
+# TYPE pipe_task_wait_seconds histogram
+pipe_task_wait_seconds_bucket{depth="1",stage="sub\"jobs",le="0.001"} 1
+pipe_task_wait_seconds_bucket{depth="1",stage="sub\"jobs",le="1"} 1
+pipe_task_wait_seconds_bucket{depth="1",stage="sub\"jobs",le="+Inf"} 1
+pipe_task_wait_seconds_sum{depth="1",stage="sub\"jobs"} 0.0005
+pipe_task_wait_seconds_count{depth="1",stage="sub\"jobs"} 1
+# HELP pipe_task_duration_seconds Execution time of the tasks.
+# TYPE pipe_task_duration_seconds histogram
+pipe_task_duration_seconds_bucket{depth="1",stage="sub\"jobs",le="0.001"} 0
+pipe_task_duration_seconds_bucket{depth="1",stage="sub\"jobs",le="1"} 1
+pipe_task_duration_seconds_bucket{depth="1",stage="sub\"jobs",le="+Inf"} 1
+pipe_task_duration_seconds_sum{depth="1",stage="sub\"jobs"} 0.002
+pipe_task_duration_seconds_count{depth="1",stage="sub\"jobs"} 1
+`)
+	})
+
+	t.Run("breakers", func(t *testing.T) {
+		// Arrange
+		collector := pipe.NewCollector()
+		collector.BreakerChange(pipe.BreakerEvent{Name: "api", From: pipe.BreakerClosed, To: pipe.BreakerOpen})
+		collector.BreakerChange(pipe.BreakerEvent{Name: "api", From: pipe.BreakerOpen, To: pipe.BreakerHalfOpen})
+		var buf bytes.Buffer
+
+		// Act
+		_, err := prom.NewExporter(nil, collector).WriteTo(&buf)
+
+		// Assert
+		td.CmpNoError(t, err)
+		td.CmpContains(t, buf.String(), `# HELP pipe_breaker_state State of a circuit breaker: 0 closed, 1 open, 2 half-open.
+# TYPE pipe_breaker_state gauge
+pipe_breaker_state{breaker="api"} 2
+# HELP pipe_breaker_opened_total Number of times a circuit breaker opened.
+# TYPE pipe_breaker_opened_total counter
+pipe_breaker_opened_total{breaker="api"} 1
+`)
+	})
+
+	t.Run("http_handler", func(t *testing.T) {
+		// Arrange
+		pools, err := pipe.NewPools(1)
+		td.Require(t).CmpNoError(err)
+		t.Cleanup(pools.Release)
+		recorder := httptest.NewRecorder()
+
+		// Act
+		prom.NewExporter(pools, nil).ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+		// Assert
+		td.Cmp(t, recorder.Header().Get("Content-Type"), prom.ContentType)
+		td.CmpContains(t, recorder.Body.String(), `pipe_pool_capacity{depth="0"} 1`)
+		td.CmpNot(t, recorder.Body.String(), td.Contains("pipe_tasks_submitted_total"))
+	})
+}
@@ -0,0 +1,68 @@
+package pipe
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket: it allows rate items per second, with bursts of burst items.
+// It can be shared by several RateLimit processes, in several pipelines.
+type RateLimiter struct {
+	mutex  sync.Mutex
+	rate   float64 // tokens per second, no limit if not positive
+	burst  float64
+	tokens float64 // negative when tokens are reserved ahead
+	last   time.Time
+}
+
+// NewRateLimiter creates a full RateLimiter of rate items per second, with bursts of burst items (at least 1).
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	b := float64(max(burst, 1))
+	return &RateLimiter{rate: rate, burst: b, tokens: b, last: time.Now()}
+}
+
+// reserve takes a token, and returns how long to wait before using it.
+func (l *RateLimiter) reserve() time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// RateLimit decorates an ErrProcess, so the items are processed at the rate of limiter.
+//
+// Like the backoffs of Retry, the waits for a token return a *Deferred error, so they don't hold a worker of the pools:
+// the item is submitted again to the pool of its depth once its token is available. See Deferred for the callers which resume it.
+// An item deferred by proc, like a failed attempt of a Retry, takes a new token once resumed.
+func RateLimit[T any](limiter *RateLimiter, proc ErrProcess[T]) ErrProcess[T] {
+	return func(pool *Pools, t T) (T, error) {
+		if wait := limiter.reserve(); wait > 0 {
+			return t, deferItem(wait, nil, func(pool *Pools) (T, error) {
+				return rateLimited(limiter, proc, pool, t)
+			})
+		}
+		return rateLimited(limiter, proc, pool, t)
+	}
+}
+
+// rateLimited runs proc on t, which has its token.
+func rateLimited[T any](limiter *RateLimiter, proc ErrProcess[T], pool *Pools, t T) (T, error) {
+	result, err := proc(pool, t)
+	var later *Deferred
+	if errors.As(err, &later) {
+		return result, deferItem(later.Delay, later.Err, func(pool *Pools) (T, error) {
+			return RateLimit(limiter, func(pool *Pools, _ T) (T, error) { return resumeAs[T](later, pool) })(pool, t)
+		})
+	}
+	return result, err
+}
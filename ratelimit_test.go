@@ -0,0 +1,144 @@
+package pipe_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+func TestRateLimit(t *testing.T) {
+	t.Run("rate", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 4)
+		limiter := pipe.NewRateLimiter(100, 1)
+
+		// Act
+		start := time.Now()
+		out, errc := pipe.PipeErr(pool, lo.SliceToChannel(0, lo.Range(6)), pipe.RateLimit(limiter, pipe.AsErrProcess(identity[int])), nil)
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), td.Bag(0, 1, 2, 3, 4, 5))
+		td.CmpNoError(t, <-errc)
+		td.Cmp(t, time.Since(start), td.Gte(45*time.Millisecond), "5 items wait for a token every 10ms")
+	})
+
+	t.Run("shared", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 4, 4)
+		limiter := pipe.NewRateLimiter(100, 2)
+		dispatcher, _ := pipe.NewDispatch(func(parent int, in chan<- int) {
+			for i := 0; i < parent; i++ {
+				in <- i
+			}
+		}, func(_ int, out <-chan int) int {
+			return len(lo.ChannelToSlice(out))
+		})
+		limited := pipe.WrapErr(pipe.RateLimit(limiter, pipe.AsErrProcess(identity[int])), dispatcher, nil)
+
+		// Act
+		start := time.Now()
+		var wg sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				td.CmpNoError(t, pipe.RunErr(pool, lo.SliceToChannel(0, []int{4}), limited, nil))
+			}()
+		}
+		wg.Wait()
+
+		// Assert
+		td.Cmp(t, time.Since(start), td.Gte(55*time.Millisecond), "8 childs of both pipelines share the burst of 2 and a token every 10ms")
+	})
+
+	t.Run("no_worker_held", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1)
+		limiter := pipe.NewRateLimiter(5, 1)
+		limitedDone := make(chan error)
+		go func() {
+			limitedDone <- pipe.RunErr(pool, lo.SliceToChannel(0, []int{1, 2}), pipe.RateLimit(limiter, pipe.AsErrProcess(identity[int])), nil)
+		}()
+		time.Sleep(20 * time.Millisecond) // the second item waits for its token
+
+		// Act
+		start := time.Now()
+		err := pipe.Run(pool, lo.SliceToChannel(0, []int{3}), identity[int])
+
+		// Assert
+		td.CmpNoError(t, err)
+		td.Cmp(t, time.Since(start), td.Lt(100*time.Millisecond), "The single worker is available while the limited item waits")
+		td.CmpNoError(t, <-limitedDone)
+	})
+
+	t.Run("with_retry", func(t *testing.T) {
+		for name, compose := range map[string]func(*pipe.RateLimiter, pipe.ErrProcess[int]) pipe.ErrProcess[int]{
+			"rate_limit_around_retry": func(limiter *pipe.RateLimiter, proc pipe.ErrProcess[int]) pipe.ErrProcess[int] {
+				return pipe.RateLimit(limiter, pipe.Retry(proc, 5, pipe.ConstantBackoff(0), nil))
+			},
+			"retry_around_rate_limit": func(limiter *pipe.RateLimiter, proc pipe.ErrProcess[int]) pipe.ErrProcess[int] {
+				return pipe.Retry(pipe.RateLimit(limiter, proc), 5, pipe.ConstantBackoff(0), nil)
+			},
+		} {
+			compose := compose
+			t.Run(name, func(t *testing.T) {
+				// Arrange
+				pool := InitPool(t, 2)
+				limiter := pipe.NewRateLimiter(100, 1)
+				proc := flaky{failures: 100}
+
+				// Act
+				start := time.Now()
+				err := pipe.RunErr(pool, lo.SliceToChannel(0, []int{1}), compose(limiter, proc.process), nil)
+
+				// Assert
+				td.CmpErrorIs(t, err, errOdd)
+				td.Cmp(t, proc.attempts, map[int]int{1: 5})
+				td.Cmp(t, time.Since(start), td.Gte(35*time.Millisecond), "Each attempt waits for a token every 10ms")
+			})
+		}
+	})
+
+	t.Run("backpressure", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1)
+		limiter := pipe.NewRateLimiter(10, 1)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var read atomic.Int32
+		in := make(chan int)
+		go func() {
+			defer close(in)
+			for i := 0; i < 1000; i++ {
+				select {
+				case in <- i:
+					read.Add(1)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		done := make(chan error, 1)
+		go func() {
+			done <- pipe.RunErr(pool.WithContext(ctx), in, pipe.RateLimit(limiter, pipe.AsErrProcess(identity[int])), nil)
+		}()
+		time.Sleep(100 * time.Millisecond)
+
+		// Act
+		consumed := read.Load()
+		start := time.Now()
+		cancel()
+		err := <-done
+
+		// Assert
+		td.Cmp(t, consumed, td.Lte(int32(4)), "The items waiting for a token hold back the input")
+		td.CmpErrorIs(t, err, context.Canceled)
+		td.Cmp(t, time.Since(start), td.Lt(100*time.Millisecond), "The waits are over once the context is done")
+	})
+}
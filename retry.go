@@ -0,0 +1,150 @@
+package pipe
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Deferred is the error of an item which asks to be processed again after a delay, like a failed attempt of a Retry or a wait for a token
+// of a RateLimit. It is detected with errors.As, so it may be wrapped, but the resumed item goes on from the process which deferred it.
+//
+// The error aware pipes (PipeErr, WrapErr, RunErr...) and LinkErr resume the deferred items: they don't hold a worker while waiting,
+// then submit the item again to the pool of its depth, unless the context of the pools is done meanwhile. WithTimeout and Breaker hand
+// the deferral over to their caller, and resume the item under their deadline and their circuit. Any other caller, like a process calling
+// a Retry directly or a Switch route, which can't fail, should decorate it with Await.
+//
+// The item is resumed as the type of the process which deferred it: a process passing on the deferral of an item of another type fails with ErrDeferredType.
+type Deferred struct {
+	Delay  time.Duration
+	Err    error // error of the last attempt, if any
+	resume any   // func(*Pools) (T, error) resuming the item of type T, which may defer it again
+}
+
+// ErrDeferredType is the error of a deferred item resumed as another type than the one of the process which deferred it.
+var ErrDeferredType = errors.New("deferred item resumed as another type")
+
+// deferItem returns the deferral of an item of type T, resumed by resume.
+func deferItem[T any](delay time.Duration, err error, resume func(*Pools) (T, error)) *Deferred {
+	return &Deferred{Delay: delay, Err: err, resume: resume}
+}
+
+// Error implements the error interface.
+func (d *Deferred) Error() string {
+	if d.Err == nil {
+		return fmt.Sprintf("item deferred for %v", d.Delay)
+	}
+	return fmt.Sprintf("item deferred for %v: %v", d.Delay, d.Err)
+}
+
+// Unwrap returns the error of the last attempt.
+func (d *Deferred) Unwrap() error {
+	return d.Err
+}
+
+// then returns a deferral of an item of type T, which goes on with next once resumed.
+func then[T any](d *Deferred, next func(*Pools, T) (T, error)) *Deferred {
+	return deferItem(d.Delay, d.Err, func(pool *Pools) (T, error) {
+		result, err := resumeAs[T](d, pool)
+		var later *Deferred
+		if errors.As(err, &later) {
+			return result, then(later, next)
+		}
+		if err != nil {
+			return result, err
+		}
+		return next(pool, result)
+	})
+}
+
+// resumeAs resumes the item of a deferral, which fails with ErrDeferredType if it is not of type T.
+func resumeAs[T any](d *Deferred, pool *Pools) (T, error) {
+	resume, ok := d.resume.(func(*Pools) (T, error))
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("%w: %T can't resume a %T", ErrDeferredType, d.resume, zero)
+	}
+	return resume(pool)
+}
+
+// Await decorates an ErrProcess, so the items it defers, like the attempts of a Retry, are resumed in the routine of the item.
+// Unlike the error aware pipes, it holds the worker of the item while waiting. A deferred item fails once the context of the pools is done.
+func Await[T any](proc ErrProcess[T]) ErrProcess[T] {
+	return func(pool *Pools, t T) (T, error) {
+		result, err := proc(pool, t)
+		for {
+			var later *Deferred
+			if !errors.As(err, &later) {
+				return result, err
+			}
+			timer := time.NewTimer(later.Delay)
+			select {
+			case <-timer.C:
+			case <-pool.Context().Done():
+				timer.Stop()
+				return t, errors.Join(later.Err, pool.Context().Err())
+			}
+			result, err = resumeAs[T](later, pool)
+		}
+	}
+}
+
+// Backoff returns the delay before a retry, from 1 for the first retry.
+type Backoff func(retry int) time.Duration
+
+// ConstantBackoff is a Backoff which always waits delay.
+func ConstantBackoff(delay time.Duration) Backoff {
+	return func(int) time.Duration { return delay }
+}
+
+// ExponentialBackoff is a Backoff which waits initial, then doubles the delay at each retry, up to maximum.
+func ExponentialBackoff(initial, maximum time.Duration) Backoff {
+	return func(retry int) time.Duration {
+		delay := initial
+		for i := 1; i < retry && delay < maximum; i++ {
+			delay *= 2
+		}
+		return min(delay, maximum)
+	}
+}
+
+// JitterBackoff decorates a Backoff with a full jitter: the delay is random between 0 and the delay of backoff,
+// so the retries of items failing together are spread.
+func JitterBackoff(backoff Backoff) Backoff {
+	return func(retry int) time.Duration {
+		delay := backoff(retry)
+		if delay <= 0 {
+			return 0
+		}
+		return time.Duration(rand.Int63n(int64(delay) + 1))
+	}
+}
+
+// Retry decorates an ErrProcess, so a failed item is processed again, up to attempts times in total, waiting for backoff between the attempts.
+// retryable tells which errors are retried, every error if it is nil. The item fails with the error of its last attempt.
+//
+// A failed attempt returns a *Deferred error, so the backoff waits don't hold a worker of the pools: see Deferred for the callers which resume it.
+// The item is not retried anymore once the context of the pools is done. An item deferred by proc, like a wait of a RateLimit,
+// goes on with the same attempt once resumed.
+func Retry[T any](proc ErrProcess[T], attempts int, backoff Backoff, retryable func(error) bool) ErrProcess[T] {
+	var attempt func(pool *Pools, t T, n int, run ErrProcess[T]) (T, error)
+	attempt = func(pool *Pools, t T, n int, run ErrProcess[T]) (T, error) {
+		result, err := run(pool, t)
+		var later *Deferred
+		if errors.As(err, &later) {
+			return result, deferItem(later.Delay, later.Err, func(pool *Pools) (T, error) {
+				return attempt(pool, t, n, func(pool *Pools, _ T) (T, error) { return resumeAs[T](later, pool) })
+			})
+		}
+		if err == nil || n >= attempts || (retryable != nil && !retryable(err)) || pool.Context().Err() != nil {
+			return result, err
+		}
+		return result, deferItem(backoff(n), err, func(pool *Pools) (T, error) {
+			return attempt(pool, t, n+1, proc)
+		})
+	}
+	return func(pool *Pools, t T) (T, error) {
+		return attempt(pool, t, 1, proc)
+	}
+}
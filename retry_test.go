@@ -0,0 +1,275 @@
+package pipe_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+// flaky counts the attempts of each item, and fails the first failures attempts.
+type flaky struct {
+	mutex    sync.Mutex
+	failures int
+	attempts map[int]int
+}
+
+func (f *flaky) process(_ *pipe.Pools, i int) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.attempts == nil {
+		f.attempts = map[int]int{}
+	}
+	f.attempts[i]++
+	if f.attempts[i] <= f.failures {
+		return i, errOdd
+	}
+	return i, nil
+}
+
+func TestRetry(t *testing.T) {
+	t.Run("until_success", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2)
+		proc := flaky{failures: 2}
+
+		// Act
+		out, errc := pipe.PipeErr(pool, lo.SliceToChannel(0, []int{1, 2}), pipe.Retry(proc.process, 3, pipe.ConstantBackoff(time.Millisecond), nil), nil)
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), td.Bag(1, 2))
+		td.CmpNoError(t, <-errc)
+		td.Cmp(t, proc.attempts, map[int]int{1: 3, 2: 3})
+	})
+
+	t.Run("max_attempts", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2)
+		proc := flaky{failures: 5}
+
+		// Act
+		err := pipe.RunErr(pool, lo.SliceToChannel(0, []int{1}), pipe.Retry(proc.process, 3, pipe.ConstantBackoff(time.Millisecond), nil), nil)
+
+		// Assert
+		var itemErr *pipe.ItemError[int]
+		td.Require(t).True(errors.As(err, &itemErr))
+		td.Cmp(t, itemErr.Err, errOdd, "The item fails with its last error")
+		td.Cmp(t, proc.attempts, map[int]int{1: 3})
+	})
+
+	t.Run("not_retryable", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2)
+		proc := flaky{failures: 5}
+		retryable := func(err error) bool { return !errors.Is(err, errOdd) }
+
+		// Act
+		err := pipe.RunErr(pool, lo.SliceToChannel(0, []int{1}), pipe.Retry(proc.process, 3, pipe.ConstantBackoff(time.Millisecond), retryable), nil)
+
+		// Assert
+		td.CmpErrorIs(t, err, errOdd)
+		td.Cmp(t, proc.attempts, map[int]int{1: 1})
+	})
+
+	t.Run("backoff_frees_worker", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1)
+		proc := flaky{failures: 1}
+		first := func(pool *pipe.Pools, i int) (int, error) {
+			if i == 2 {
+				return i, nil
+			}
+			return proc.process(pool, i)
+		}
+
+		// Act
+		out, errc := pipe.PipeErr(pool, lo.SliceToChannel(0, []int{1, 2}), pipe.Retry(first, 2, pipe.ConstantBackoff(50*time.Millisecond), nil), nil)
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), []int{2, 1}, "The single worker processes 2 while 1 waits for its retry")
+		td.CmpNoError(t, <-errc)
+	})
+
+	t.Run("link_err", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2)
+		proc := flaky{failures: 1}
+		add := func(_ *pipe.Pools, i int) (int, error) { return i + 10, nil }
+
+		// Act
+		out, errc := pipe.PipeErr(pool, lo.SliceToChannel(0, []int{1, 2}),
+			pipe.LinkErr(pipe.Retry(proc.process, 2, pipe.ConstantBackoff(time.Millisecond), nil), add), nil)
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), td.Bag(11, 12), "The chain goes on after the retry")
+		td.CmpNoError(t, <-errc)
+	})
+
+	t.Run("wrap_err_childs", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1, 1)
+		proc := flaky{failures: 1}
+		dispatcher, _ := pipe.NewDispatch(func(parent int, in chan<- int) {
+			for i := 0; i < parent; i++ {
+				in <- i
+			}
+		}, func(_ int, out <-chan int) int {
+			return lo.Sum(lo.ChannelToSlice(out))
+		})
+
+		// Act
+		out, errc := pipe.PipeErr(pool, lo.SliceToChannel(0, []int{4}),
+			pipe.WrapErr(pipe.Retry(proc.process, 2, pipe.ConstantBackoff(time.Millisecond), nil), dispatcher, nil), nil)
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), []int{6})
+		td.CmpNoError(t, <-errc)
+	})
+
+	t.Run("wrapped_deferral", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2)
+		proc := flaky{failures: 1}
+		retry := pipe.Retry(proc.process, 2, pipe.ConstantBackoff(time.Millisecond), nil)
+		wrapping := func(pool *pipe.Pools, i int) (int, error) {
+			result, err := retry(pool, i)
+			if err != nil {
+				return result, fmt.Errorf("calling the service: %w", err)
+			}
+			return result, nil
+		}
+
+		// Act
+		out, errc := pipe.PipeErr(pool, lo.SliceToChannel(0, []int{1}), wrapping, nil)
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), []int{1})
+		td.CmpNoError(t, <-errc)
+		td.Cmp(t, proc.attempts, map[int]int{1: 2})
+	})
+
+	t.Run("deferred_attempt", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2)
+		proc := flaky{failures: 100}
+		limiter := pipe.NewRateLimiter(1000, 1) // most attempts wait for a token
+
+		// Act
+		err := pipe.RunErr(pool, lo.SliceToChannel(0, lo.Range(4)),
+			pipe.Retry(pipe.RateLimit(limiter, proc.process), 3, pipe.ConstantBackoff(time.Millisecond), nil), nil)
+
+		// Assert
+		td.CmpErrorIs(t, err, errOdd)
+		td.Cmp(t, proc.attempts, map[int]int{0: 3, 1: 3, 2: 3, 3: 3}, "A wait for a token is not an attempt")
+	})
+
+	t.Run("resumed_as_another_type", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1)
+		failOnce := true
+		retry := pipe.Retry(func(_ *pipe.Pools, s string) (string, error) {
+			if failOnce {
+				failOnce = false
+				return s, errOdd
+			}
+			return s, nil
+		}, 2, pipe.ConstantBackoff(time.Millisecond), nil)
+		length := func(pool *pipe.Pools, s string) (int, error) {
+			_, err := retry(pool, s) // the deferral of a string, given up by a process of int
+			return len(s), err
+		}
+
+		// Act
+		out, errc := pipe.PipeErr(pool, lo.SliceToChannel(0, []string{"abc"}), length, nil)
+
+		// Assert
+		td.CmpEmpty(t, lo.ChannelToSlice(out))
+		td.CmpErrorIs(t, <-errc, pipe.ErrDeferredType)
+	})
+
+	t.Run("await", func(t *testing.T) {
+		// Arrange
+		proc := flaky{failures: 2}
+
+		// Act
+		result, err := pipe.Await(pipe.Retry(proc.process, 3, pipe.ConstantBackoff(time.Millisecond), nil))(nil, 1)
+
+		// Assert
+		td.CmpNoError(t, err)
+		td.Cmp(t, result, 1)
+		td.Cmp(t, proc.attempts, map[int]int{1: 3})
+	})
+
+	t.Run("with_timeout", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2)
+		proc := flaky{failures: 100}
+
+		// Act
+		start := time.Now()
+		err := pipe.RunErr(pool, lo.SliceToChannel(0, []int{1}),
+			pipe.WithTimeout(30*time.Millisecond, pipe.Retry(proc.process, 100, pipe.ConstantBackoff(10*time.Millisecond), nil)), nil)
+
+		// Assert
+		td.CmpErrorIs(t, err, pipe.ErrTimeout)
+		td.Cmp(t, time.Since(start), td.Lt(200*time.Millisecond), "The attempts share the deadline of the item")
+		td.Cmp(t, proc.attempts[1], td.Between(2, 4))
+	})
+
+	t.Run("breaker_around_retry", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1)
+		proc := flaky{failures: 100}
+		breaker := pipe.NewCircuitBreaker("api", 2, time.Hour)
+
+		// Act
+		err := pipe.RunErr(pool, lo.SliceToChannel(0, []int{1}),
+			pipe.Breaker(breaker, pipe.Retry(proc.process, 5, pipe.ConstantBackoff(time.Millisecond), nil), nil), nil)
+
+		// Assert
+		td.CmpErrorIs(t, err, pipe.ErrCircuitOpen, "Each attempt goes through the breaker")
+		td.Cmp(t, proc.attempts, map[int]int{1: 2})
+	})
+
+	t.Run("cancelled_backoff", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1)
+		ctx, cancel := context.WithCancel(context.Background())
+		proc := flaky{failures: 1}
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			cancel()
+		}()
+
+		// Act
+		start := time.Now()
+		err := pipe.RunErr(pool.WithContext(ctx), lo.SliceToChannel(0, []int{1}), pipe.Retry(proc.process, 2, pipe.ConstantBackoff(time.Hour), nil), nil)
+
+		// Assert
+		td.CmpErrorIs(t, err, context.Canceled)
+		td.CmpErrorIs(t, err, errOdd, "The item fails with its last error")
+		td.Cmp(t, time.Since(start), td.Lt(time.Second), "The backoff is over once the context is done")
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	t.Run("exponential", func(t *testing.T) {
+		backoff := pipe.ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+		td.Cmp(t, lo.Map([]int{1, 2, 3, 4, 100}, func(retry, _ int) time.Duration { return backoff(retry) }),
+			[]time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 50 * time.Millisecond, 50 * time.Millisecond})
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		backoff := pipe.JitterBackoff(pipe.ConstantBackoff(10 * time.Millisecond))
+		for i := 0; i < 100; i++ {
+			td.Cmp(t, backoff(1), td.Between(time.Duration(0), 10*time.Millisecond))
+		}
+		td.Cmp(t, pipe.JitterBackoff(pipe.ConstantBackoff(0))(1), time.Duration(0))
+	})
+}
@@ -0,0 +1,76 @@
+package pipe
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// Route is a route of a Switch: a process, whose runs are named for the observers, the logs and the traces.
+type Route[T any] struct {
+	Name    string
+	Process PoolProcess[T] // nil lets the items through unchanged
+	Pools   *Pools         // optional dedicated pools, the route runs in the routine of the item if nil
+}
+
+// Switch creates a PoolProcess which routes each item to the route of its key. The items without route go through unchanged.
+//
+// The run of a route is a task named after the route for the observers, so each route has its own metrics. A route with dedicated pools
+// is submitted to them, and its process receives their next depth; otherwise it runs in the routine of the item, with the pools of the item.
+// Like Wrap, Switch panics on a failed submission to dedicated pools, and when a route panics. An item dropped by the SubmitDrop policy
+// of dedicated pools goes through unchanged, like an item without route: the drop is only reported to the onDrop callback, the observers and the logger.
+func Switch[K comparable, T any](key func(T) K, routes map[K]Route[T]) PoolProcess[T] {
+	return func(pool *Pools, t T) T {
+		route, ok := routes[key(t)]
+		if !ok || route.Process == nil {
+			return t
+		}
+		if route.Pools == nil {
+			pool.observe(route.Name, itemKey(t), func() error {
+				t = route.Process(pool.WithName(route.Name), t)
+				return nil
+			})
+			return t
+		}
+		return route.run(pool, t)
+	}
+}
+
+// If creates a PoolProcess which routes the items matching predicate to then, the others to otherwise. See Switch.
+func If[T any](predicate func(T) bool, then, otherwise Route[T]) PoolProcess[T] {
+	return Switch(predicate, map[bool]Route[T]{true: then, false: otherwise})
+}
+
+// run submits the item to the dedicated pools of the route, and waits for its result.
+func (r Route[T]) run(pool *Pools, t T) T {
+	done := make(chan struct{})
+	var panicErr *PanicError
+	err := r.Pools.WithContext(pool.Context()).WithName(r.Name).submit(itemKey(t), func(routePools *Pools) (err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				panicErr = &PanicError{Value: v, Stack: debug.Stack(), Depth: r.Pools.depth, Stage: r.Name, Item: itemKey(t)}
+				err = panicErr // notified as a panic
+			}
+		}()
+		t = r.Process(routePools, t)
+		return nil
+	}, func() { close(done) })
+	<-done
+	panicOnSubmitError(err) // a dropped item goes through unchanged
+	if panicErr != nil {
+		panic(panicErr.Value) // in the routine of the item
+	}
+	return t
+}
+
+// observe runs task in the current routine as a task of the depth of the item processed with p, named for the observers.
+func (p *Pools) observe(name, key string, task func() error) {
+	if p == nil || p.config == nil {
+		_ = task()
+		return
+	}
+	ev := Event{Depth: max(p.depth-1, 0), Name: name, Key: key}
+	stats := &levelStats{} // the routine of the item is already counted
+	stats.waiting.Add(1)
+	p.config.notify(Observer.Submit, ev)
+	p.config.run(p.Context(), stats, ev, time.Now(), nil, task)
+}
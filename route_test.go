@@ -0,0 +1,110 @@
+package pipe_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+func TestSwitch(t *testing.T) {
+	parity := func(i int) string {
+		if i%2 == 0 {
+			return "even"
+		}
+		return "odd"
+	}
+
+	t.Run("route_metrics", func(t *testing.T) {
+		// Arrange
+		collector := pipe.NewCollector()
+		pool := InitPoolWithConfig(t, []int{2, 3}, pipe.WithObserver(collector))
+		routes := map[string]pipe.Route[int]{
+			"even": {Name: "halve", Process: func(dp *pipe.Pools, i int) int {
+				td.Cmp(t, dp.Sizes(), []int{3}, "Routes without pools run with the pools of the item")
+				return i / 2
+			}},
+			"odd": {Name: "negate", Process: func(_ *pipe.Pools, i int) int { return -i }},
+		}
+
+		// Act
+		out := pipe.Pipe(pool, lo.SliceToChannel(0, lo.Range(5)), pipe.Switch(parity, routes))
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), td.Bag(0, 1, 2, -1, -3))
+		finished := lo.Map(collector.Snapshot(), func(m pipe.Metrics, _ int) any { return lo.T3(m.Depth, m.Name, m.Finished) })
+		td.Cmp(t, finished, []any{lo.T3(0, "", int64(5)), lo.T3(0, "halve", int64(3)), lo.T3(0, "negate", int64(2))})
+	})
+
+	t.Run("dedicated_pools", func(t *testing.T) {
+		// Arrange
+		collector := pipe.NewCollector()
+		pool := InitPool(t, 2, 3)
+		dedicated := InitPoolWithConfig(t, []int{1, 4}, pipe.WithObserver(collector))
+		routes := map[string]pipe.Route[int]{
+			"odd": {Name: "slow", Pools: dedicated, Process: func(dp *pipe.Pools, i int) int {
+				td.Cmp(t, dp.Sizes(), []int{4}, "Routes with pools receive their next depth")
+				return i * 10
+			}},
+		}
+
+		// Act
+		out := pipe.Pipe(pool, lo.SliceToChannel(0, lo.Range(4)), pipe.Switch(parity, routes))
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), td.Bag(0, 10, 2, 30), "Items without route go through")
+		td.Cmp(t, lo.Map(collector.Snapshot(), func(m pipe.Metrics, _ int) any { return lo.T2(m.Name, m.Finished) }), []any{lo.T2("slow", int64(2))})
+	})
+
+	t.Run("dedicated_pools_drop", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1)
+		var dropped []*pipe.SubmitError
+		dedicated := InitPoolWithConfig(t, []int{1}, pipe.WithSubmitPolicy(pipe.SubmitDrop(func(err *pipe.SubmitError) {
+			dropped = append(dropped, err)
+		})))
+		dedicated.Release()
+		routes := map[string]pipe.Route[int]{
+			"odd": {Name: "released", Pools: dedicated, Process: func(_ *pipe.Pools, i int) int { return i * 10 }},
+		}
+
+		// Act
+		out := pipe.Pipe(pool, lo.SliceToChannel(0, []int{1, 2}), pipe.Switch(parity, routes))
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), []int{1, 2}, "A dropped item goes through unchanged")
+		td.CmpLen(t, dropped, 1)
+	})
+
+	t.Run("dedicated_pools_panic", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1)
+		dedicated := InitPool(t, 1)
+		routes := map[string]pipe.Route[int]{
+			"odd": {Name: "boom", Pools: dedicated, Process: func(*pipe.Pools, int) int { panic(errOdd) }},
+		}
+
+		// Act
+		err := pipe.RunErr(pool, lo.SliceToChannel(0, []int{1}), pipe.AsErrProcess(pipe.Switch(parity, routes)), nil)
+
+		// Assert
+		var panicErr *pipe.PanicError
+		td.Require(t).True(errors.As(err, &panicErr))
+		td.Cmp(t, panicErr.Value, errOdd)
+	})
+}
+
+func TestIf(t *testing.T) {
+	// Arrange
+	pool := InitPool(t, 2)
+	positive := func(i int) bool { return i > 0 }
+
+	// Act
+	out := pipe.Pipe(pool, lo.SliceToChannel(0, []int{-1, 0, 2}),
+		pipe.If(positive, pipe.Route[int]{Name: "double", Process: func(_ *pipe.Pools, i int) int { return i * 2 }}, pipe.Route[int]{}))
+
+	// Assert
+	td.Cmp(t, lo.ChannelToSlice(out), td.Bag(-1, 0, 4))
+}
@@ -0,0 +1,67 @@
+package pipe
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/samber/lo"
+)
+
+// ErrDepthMismatch is returned when the depth of a pipeline doesn't fit the depth of the Pools.
+var ErrDepthMismatch = errors.New("pools depth mismatch")
+
+// Stage is a PoolProcess which carries the description of its nesting depth, so it can be validated against Pools before any work is submitted.
+type Stage[T any] struct {
+	Process PoolProcess[T]
+	// Depth is the number of nested Wrap levels of the process: its items run at the depth 0 of the Pools, their childs at the depth 1, and so on.
+	Depth int
+}
+
+// NewStage creates a Stage from linked processes without nested levels.
+func NewStage[T any](procs ...PoolProcess[T]) Stage[T] {
+	return Stage[T]{Process: Link(procs...)}
+}
+
+// LinkStages merges several Stage to one. Its depth is the deepest of the stages.
+func LinkStages[T any](stages ...Stage[T]) Stage[T] {
+	return Stage[T]{
+		Process: Link(lo.Map(stages, func(s Stage[T], _ int) PoolProcess[T] { return s.Process })...),
+		Depth:   lo.Max(lo.Map(stages, func(s Stage[T], _ int) int { return s.Depth })),
+	}
+}
+
+// WrapStage is like Wrap, for a child Stage. The parent Stage is one level deeper than the child Stage.
+func WrapStage[Parent, Child any](child Stage[Child], dispatch Dispatch[Parent, Child]) Stage[Parent] {
+	return Stage[Parent]{Process: Wrap(child.Process, dispatch), Depth: child.Depth + 1}
+}
+
+// Validate checks that the stage fits the pools, see ValidateDepth.
+func (s Stage[T]) Validate(pools *Pools) error {
+	return ValidateDepth(pools, s.Depth)
+}
+
+// RunStage validates the stage against the pools, then runs it like RunContext with the context of the pools.
+func RunStage[T any](pool *Pools, in <-chan T, stage Stage[T]) error {
+	if err := stage.Validate(pool); err != nil {
+		return err
+	}
+	return RunErr(pool, in, AsErrProcess(stage.Process), nil)
+}
+
+// ValidateDepth checks that a pipeline with depth nested levels fits the pools. It reports every mismatch wrapped into ErrDepthMismatch:
+//
+//   - a nested level without pool, which would silently run inline. A size of 0 is an explicit choice and is valid.
+//   - a non-zero pool size at a depth which can never be reached.
+func ValidateDepth(pools *Pools, depth int) error {
+	sizes := pools.Sizes()
+	var errs []error
+	for level := len(sizes); level <= depth; level++ {
+		errs = append(errs, fmt.Errorf("%w: depth %d has no pool", ErrDepthMismatch, level))
+	}
+	for level := depth + 1; level < len(sizes); level++ {
+		if sizes[level] != 0 {
+			errs = append(errs, fmt.Errorf("%w: pool of size %d at depth %d is never reached by a pipeline of depth %d", ErrDepthMismatch, sizes[level], level, depth))
+		}
+	}
+	return errors.Join(errs...)
+}
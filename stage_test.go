@@ -0,0 +1,93 @@
+package pipe_test
+
+import (
+	"testing"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+func TestStage(t *testing.T) {
+	dispatcher, _ := pipe.NewDispatch(func(parent int, in chan<- int) {
+		in <- parent
+	}, func(_ int, out <-chan int) int {
+		return lo.Sum(lo.ChannelToSlice(out))
+	})
+	nested := pipe.LinkStages(
+		pipe.NewStage(identity[int]),
+		pipe.WrapStage(pipe.WrapStage(pipe.NewStage(identity[int]), dispatcher), dispatcher),
+	)
+
+	t.Run("depth", func(t *testing.T) {
+		td.Cmp(t, nested.Depth, 2)
+		td.Cmp(t, pipe.NewStage(identity[int]).Depth, 0)
+	})
+
+	t.Run("sizes", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2, 0, 1)
+
+		// Act
+		sizes := pool.Sizes()
+
+		// Assert
+		td.Cmp(t, sizes, []int{2, 0, 1})
+	})
+
+	t.Run("valid_depth", func(t *testing.T) {
+		td.CmpNoError(t, nested.Validate(InitPool(t, 1, 1, 1)))
+		td.CmpNoError(t, nested.Validate(InitPool(t, 1, 0, 1, 0)), "Explicit inline and unused 0 sizes are valid")
+	})
+
+	t.Run("error_missing_pool", func(t *testing.T) {
+		// Act
+		err := nested.Validate(InitPool(t, 1))
+
+		// Assert
+		td.CmpErrorIs(t, err, pipe.ErrDepthMismatch)
+		td.CmpContains(t, err, "depth 1 has no pool")
+		td.CmpContains(t, err, "depth 2 has no pool")
+	})
+
+	t.Run("error_unreachable_pool", func(t *testing.T) {
+		// Act
+		err := pipe.NewStage(identity[int]).Validate(InitPool(t, 1, 2))
+
+		// Assert
+		td.CmpErrorIs(t, err, pipe.ErrDepthMismatch)
+		td.CmpContains(t, err, "pool of size 2 at depth 1 is never reached by a pipeline of depth 0")
+	})
+
+	t.Run("run_stage_validates", func(t *testing.T) {
+		// Arrange
+		called := false
+		stage := pipe.NewStage(func(_ *pipe.Pools, i int) int {
+			called = true
+			return i
+		})
+
+		// Act
+		err := pipe.RunStage(InitPool(t, 1, 1), lo.SliceToChannel(0, []int{1}), stage)
+
+		// Assert
+		td.CmpErrorIs(t, err, pipe.ErrDepthMismatch)
+		td.CmpFalse(t, called, "Nothing should be submitted")
+	})
+
+	t.Run("run_stage", func(t *testing.T) {
+		// Arrange
+		var result int
+		stage := pipe.LinkStages(nested, pipe.NewStage(func(_ *pipe.Pools, i int) int {
+			result = i
+			return i
+		}))
+
+		// Act
+		err := pipe.RunStage(InitPool(t, 1, 1, 1), lo.SliceToChannel(0, []int{3}), stage)
+
+		// Assert
+		td.CmpNoError(t, err)
+		td.Cmp(t, result, 3)
+	})
+}
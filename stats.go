@@ -0,0 +1,88 @@
+package pipe
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LevelStats is a snapshot of the activity of the pool of a depth.
+type LevelStats struct {
+	Depth    int
+	Capacity int // 0 for a depth which runs in its parent routine
+	Running  int // tasks running in the pool
+	Waiting  int // tasks submitted to the pool, waiting for a worker
+
+	BufferedIn  int // childs waiting in the buffered channels towards the pool, see WithBuffers
+	BufferedOut int // outputs waiting in the buffered channels from the pool
+	Detached    int // timed out items whose process still runs outside the pool, see WithTimeout
+}
+
+// Saturated tells if all the workers of the pool are running.
+func (s LevelStats) Saturated() bool {
+	return s.Capacity > 0 && s.Running >= s.Capacity
+}
+
+// levelStats holds the live counters of a depth.
+type levelStats struct {
+	capacity int // 0 for a depth which runs in its parent routine
+	running  atomic.Int64
+	waiting  atomic.Int64
+	detached atomic.Int64 // timed out items still running, see WithTimeout
+
+	mutex    sync.Mutex
+	buffers  map[*buffer]struct{} // open buffered channels
+	draining map[*buffer]struct{} // closed buffered channels still holding items
+}
+
+// Stats returns a snapshot of the activity of each remaining depth.
+func (p *Pools) Stats() []LevelStats {
+	sizes := p.Sizes()
+	result := make([]LevelStats, len(sizes))
+	for i, size := range sizes {
+		stats := p.config.level(p.depth + i)
+		in, out := stats.buffered()
+		result[i] = LevelStats{
+			Depth:       p.depth + i,
+			Capacity:    size,
+			Running:     int(stats.running.Load()),
+			Waiting:     int(stats.waiting.Load()),
+			BufferedIn:  in,
+			BufferedOut: out,
+			Detached:    int(stats.detached.Load()),
+		}
+	}
+	return result
+}
+
+// itemStats returns the counters of the depth of the items processed with the pools, which are the childs pools of their task,
+// and the number of workers of the depth. Items processed without parent task have throwaway counters.
+func (p *Pools) itemStats() (*levelStats, int) {
+	if p == nil || p.config == nil || p.depth == 0 {
+		return &levelStats{}, 0
+	}
+	stats := p.config.level(p.depth - 1)
+	return stats, stats.capacity
+}
+
+// level returns the counters of a depth. Pools without configuration have throwaway counters.
+func (c *poolsConfig) level(depth int) *levelStats {
+	if c == nil || depth >= len(c.levels) {
+		return &levelStats{}
+	}
+	return c.levels[depth]
+}
+
+// started moves a task of a depth from waiting to running.
+func (s *levelStats) started() {
+	s.waiting.Add(-1)
+	s.running.Add(1)
+}
+
+// done records the completion of a running task of a depth.
+func (c *poolsConfig) done(s *levelStats) {
+	s.running.Add(-1)
+	if c != nil {
+		c.lastDone.Store(time.Now().UnixNano())
+	}
+}
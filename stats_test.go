@@ -0,0 +1,53 @@
+package pipe_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+func TestStats(t *testing.T) {
+	t.Run("running_and_waiting", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1, 0)
+		in := lo.SliceToChannel(0, lo.Range(2))
+		started := make(chan bool)
+		release := make(chan bool)
+		go func() {
+			<-started
+			// The first item runs, the second one waits for the only worker
+			for pool.Stats()[0].Waiting == 0 {
+				runtime.Gosched() // wait for the second submission
+			}
+			stats := pool.Stats()
+			td.Cmp(t, stats, []pipe.LevelStats{
+				{Depth: 0, Capacity: 1, Running: 1, Waiting: 1},
+				{Depth: 1},
+			})
+			td.CmpTrue(t, stats[0].Saturated())
+			td.CmpFalse(t, stats[1].Saturated())
+			close(release)
+		}()
+
+		// Act
+		err := pipe.Run(pool, in, func(_ *pipe.Pools, i int) int {
+			if i == 0 {
+				close(started)
+				<-release
+			}
+			return i
+		})
+
+		// Assert
+		td.CmpNoError(t, err)
+		td.Cmp(t, pool.Stats(), []pipe.LevelStats{{Depth: 0, Capacity: 1}, {Depth: 1}})
+	})
+
+	t.Run("nil_pools", func(t *testing.T) {
+		var pool *pipe.Pools
+		td.CmpEmpty(t, pool.Stats())
+	})
+}
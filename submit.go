@@ -0,0 +1,101 @@
+package pipe
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/panjf2000/ants/v2"
+)
+
+// errDropped is returned by submit when the task was dropped by a SubmitDrop policy.
+var errDropped = errors.New("task dropped")
+
+// SubmitError is the error of a task which couldn't be submitted to the pool of a depth.
+type SubmitError struct {
+	Depth int
+	Err   error
+}
+
+// Error implements the error interface.
+func (e *SubmitError) Error() string {
+	return fmt.Sprintf("submit task at depth %d: %v", e.Depth, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *SubmitError) Unwrap() error {
+	return e.Err
+}
+
+type submitMode int
+
+const (
+	submitFail submitMode = iota
+	submitRetry
+	submitInline
+	submitDrop
+)
+
+// SubmitPolicy defines what happens when a task can't be submitted to a pool, for instance a full pool built with ants.WithNonblocking, or a released pool.
+type SubmitPolicy struct {
+	mode   submitMode
+	delay  time.Duration
+	onDrop func(*SubmitError)
+}
+
+// SubmitFail is the default SubmitPolicy: the item fails with a *SubmitError, and the pipeline stops dispatching.
+func SubmitFail() SubmitPolicy {
+	return SubmitPolicy{mode: submitFail}
+}
+
+// SubmitRetry is a SubmitPolicy which waits delay and submits again, as long as the pool is overloaded and the context is not done.
+// Other errors fail like SubmitFail.
+func SubmitRetry(delay time.Duration) SubmitPolicy {
+	return SubmitPolicy{mode: submitRetry, delay: delay}
+}
+
+// SubmitInline is a SubmitPolicy which runs the task in the caller goroutine, like a pool of size 0.
+func SubmitInline() SubmitPolicy {
+	return SubmitPolicy{mode: submitInline}
+}
+
+// SubmitDrop is a SubmitPolicy which drops the item. onDrop, if not nil, is called with the submission error.
+func SubmitDrop(onDrop func(*SubmitError)) SubmitPolicy {
+	return SubmitPolicy{mode: submitDrop, onDrop: onDrop}
+}
+
+// handle applies the policy to the failed submission of task into pool, from p.
+func (s SubmitPolicy) handle(p *Pools, pool *ants.Pool, task func(), err error) error {
+	switch s.mode {
+	case submitRetry:
+		ctx := p.Context()
+		for errors.Is(err, ants.ErrPoolOverload) {
+			select {
+			case <-ctx.Done():
+				return &SubmitError{Depth: p.depth, Err: errors.Join(err, ctx.Err())}
+			case <-time.After(s.delay):
+			}
+			if err = pool.Submit(task); err == nil {
+				return nil
+			}
+		}
+	case submitInline:
+		task()
+		return nil
+	case submitDrop:
+		if s.onDrop != nil {
+			s.onDrop(&SubmitError{Depth: p.depth, Err: err})
+		}
+		return errDropped
+	case submitFail:
+	}
+	return &SubmitError{Depth: p.depth, Err: err}
+}
+
+// panicOnSubmitError keeps the historical behaviour of the processes which can't return errors: a failed submission panics.
+func panicOnSubmitError(err error) {
+	var submitErr *SubmitError
+	if errors.As(err, &submitErr) {
+		panic(err)
+	}
+}
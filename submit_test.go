@@ -0,0 +1,176 @@
+package pipe_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/panjf2000/ants/v2"
+	"github.com/samber/lo"
+)
+
+func InitPoolWithConfig(t testing.TB, poolSizes []int, opts ...pipe.PoolsOption) *pipe.Pools {
+	pools, err := pipe.NewPoolsWithConfig(poolSizes, opts...)
+	td.Require(t).CmpNoError(err)
+	t.Cleanup(pools.Release)
+	return pools
+}
+
+func TestSubmitPolicy(t *testing.T) {
+	// slow keeps the single worker busy, so the next submissions overload the nonblocking pool
+	slow := func(_ *pipe.Pools, i int) (int, error) {
+		time.Sleep(20 * time.Millisecond)
+		return i, nil
+	}
+	nonblocking := pipe.WithAntsOptions(ants.WithNonblocking(true))
+
+	t.Run("fail", func(t *testing.T) {
+		// Arrange
+		pool := InitPoolWithConfig(t, []int{1}, nonblocking)
+		in := lo.SliceToChannel(0, lo.Range(3))
+
+		// Act
+		err := pipe.RunErr(pool, in, slow, nil)
+
+		// Assert
+		var submitErr *pipe.SubmitError
+		td.Require(t).True(errors.As(err, &submitErr))
+		td.Cmp(t, submitErr.Depth, 0)
+		td.CmpErrorIs(t, err, ants.ErrPoolOverload)
+	})
+
+	t.Run("fail_run", func(t *testing.T) {
+		// Arrange
+		pool := InitPoolWithConfig(t, []int{1}, nonblocking)
+		in := lo.SliceToChannel(0, lo.Range(3))
+
+		// Act
+		err := pipe.Run(pool, in, func(p *pipe.Pools, i int) int {
+			result, _ := slow(p, i)
+			return result
+		})
+
+		// Assert
+		td.CmpErrorIs(t, err, ants.ErrPoolOverload)
+	})
+
+	t.Run("fail_pipe", func(t *testing.T) {
+		// Arrange
+		pool := InitPoolWithConfig(t, []int{1}, nonblocking)
+		in := lo.SliceToChannel(0, lo.Range(3))
+
+		// Act
+		out := pipe.Pipe(pool, in, func(p *pipe.Pools, i int) int {
+			result, _ := slow(p, i)
+			return result
+		})
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), td.Len(td.Lt(3)), "The pipe stops dispatching without panicking")
+	})
+
+	t.Run("fail_stages", func(t *testing.T) {
+		// Arrange
+		pool := InitPoolWithConfig(t, []int{1}, nonblocking)
+		process := func(p *pipe.Pools, i int) int {
+			result, _ := slow(p, i)
+			return result
+		}
+
+		// Act
+		ordered := pipe.PipeOrdered(pool, lo.SliceToChannel(0, lo.Range(3)), process, 3)
+		orderedResults := lo.ChannelToSlice(ordered)
+		byKey := pipe.PipeByKey(pool, lo.SliceToChannel(0, lo.Range(3)), func(i int) int { return i }, process, 3)
+		byKeyResults := lo.ChannelToSlice(byKey)
+		filtered := pipe.Filter(pool, lo.SliceToChannel(0, lo.Range(3)), func(p *pipe.Pools, i int) bool { return process(p, i) >= 0 })
+		filteredResults := lo.ChannelToSlice(filtered)
+
+		// Assert
+		td.Cmp(t, orderedResults, td.Len(td.Lt(3)))
+		td.Cmp(t, byKeyResults, td.Len(td.Lt(3)))
+		td.Cmp(t, filteredResults, td.Len(td.Lt(3)))
+	})
+
+	t.Run("fail_released_pool", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1)
+		pool.Release()
+		in := lo.SliceToChannel(0, lo.Range(3))
+
+		// Act
+		err := pipe.RunErr(pool, in, slow, nil)
+
+		// Assert
+		td.CmpErrorIs(t, err, ants.ErrPoolClosed)
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		// Arrange
+		pool := InitPoolWithConfig(t, []int{1}, nonblocking, pipe.WithSubmitPolicy(pipe.SubmitRetry(time.Millisecond)))
+		in := lo.SliceToChannel(0, lo.Range(3))
+
+		// Act
+		out, errc := pipe.PipeErr(pool, in, slow, nil)
+
+		// Assert
+		td.CmpBag(t, lo.ChannelToSlice(out), []any{0, 1, 2})
+		td.CmpNoError(t, <-errc)
+	})
+
+	t.Run("inline", func(t *testing.T) {
+		// Arrange
+		pool := InitPoolWithConfig(t, []int{1}, nonblocking, pipe.WithSubmitPolicy(pipe.SubmitInline(), 0))
+		in := lo.SliceToChannel(0, lo.Range(3))
+
+		// Act
+		out, errc := pipe.PipeErr(pool, in, slow, nil)
+
+		// Assert
+		td.CmpBag(t, lo.ChannelToSlice(out), []any{0, 1, 2})
+		td.CmpNoError(t, <-errc)
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		// Arrange
+		var dropped atomic.Int32
+		pool := InitPoolWithConfig(t, []int{1}, nonblocking, pipe.WithSubmitPolicy(pipe.SubmitDrop(func(err *pipe.SubmitError) {
+			td.CmpErrorIs(t, err, ants.ErrPoolOverload)
+			dropped.Add(1)
+		})))
+		in := lo.SliceToChannel(0, lo.Range(3))
+
+		// Act
+		out, errc := pipe.PipeErr(pool, in, slow, nil)
+
+		// Assert
+		results := lo.ChannelToSlice(out)
+		td.CmpNoError(t, <-errc)
+		td.Cmp(t, len(results)+int(dropped.Load()), 3)
+		td.Cmp(t, dropped.Load(), td.Gt(int32(0)))
+	})
+
+	t.Run("fail_nested_depth", func(t *testing.T) {
+		// Arrange
+		pool := InitPoolWithConfig(t, []int{1, 1}, nonblocking, pipe.WithSubmitPolicy(pipe.SubmitRetry(time.Millisecond), 0))
+		in := lo.SliceToChannel(0, []int{3})
+		dispatcher, _ := pipe.NewDispatch(func(parent int, in chan<- int) {
+			for i := 0; i < parent; i++ {
+				in <- i
+			}
+		}, func(parent int, out <-chan int) int {
+			_ = lo.ChannelToSlice(out)
+			return parent
+		})
+
+		// Act
+		err := pipe.RunErr(pool, in, pipe.WrapErr(slow, dispatcher, nil), nil)
+
+		// Assert
+		var submitErr *pipe.SubmitError
+		td.Require(t).True(errors.As(err, &submitErr))
+		td.Cmp(t, submitErr.Depth, 1)
+	})
+}
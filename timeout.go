@@ -0,0 +1,111 @@
+package pipe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+)
+
+// ErrTimeout is the error of an item which exceeded the timeout of a WithTimeout process.
+var ErrTimeout = errors.New("item timed out")
+
+// WithTimeout decorates an ErrProcess, so an item fails with ErrTimeout when its process, including its nested Wrap childs, takes longer than timeout.
+//
+// The context of the pools given to proc is cancelled on timeout, so its childs are not submitted anymore, and the worker of the item is released
+// at once: proc should honor pool.Context() to stop early, since it keeps running in the background otherwise. A depth has at most as many
+// timed out items running in the background as workers (see LevelStats.Detached): beyond, its new items fail at once with ErrTimeout.
+func WithTimeout[T any](timeout time.Duration, proc ErrProcess[T]) ErrProcess[T] {
+	return WithTimeoutFallback(timeout, proc, nil)
+}
+
+// WithTimeoutFallback is like WithTimeout, but an item which timed out gets the value of fallback instead of failing, if fallback is not nil.
+//
+// The timeout covers the whole item: when proc defers it, like a Retry, the deferral is handed over to the caller and the resumed item
+// runs until the same deadline.
+func WithTimeoutFallback[T any](timeout time.Duration, proc ErrProcess[T], fallback func(T) T) ErrProcess[T] {
+	return func(pool *Pools, t T) (T, error) {
+		return withDeadline(pool, t, time.Now().Add(timeout), timeout, proc, fallback)
+	}
+}
+
+// withDeadline runs proc on t until deadline, see WithTimeoutFallback.
+func withDeadline[T any](pool *Pools, t T, deadline time.Time, timeout time.Duration, proc ErrProcess[T], fallback func(T) T) (T, error) {
+	type result struct {
+		value    T
+		err      error
+		panicked any
+		stack    []byte
+	}
+	if !time.Now().Before(deadline) { // resumed too late
+		return timedOut(pool, t, timeout, fallback)
+	}
+	stats, workers := pool.itemStats()
+	if detached := stats.detached.Load(); detached >= int64(max(workers, 1)) {
+		if fallback != nil {
+			return fallback(t), nil
+		}
+		return t, fmt.Errorf("%w: %d timed out items of the depth still running", ErrTimeout, detached)
+	}
+	ctx, cancel := context.WithDeadline(pool.Context(), deadline)
+	defer cancel()
+	done := make(chan result, 1)
+	var over atomic.Bool // set by the first of the item and its caller to give up on the other
+	go func() {
+		defer func() {
+			if over.Swap(true) { // detached by its caller
+				stats.detached.Add(-1)
+			}
+		}()
+		defer func() {
+			if v := recover(); v != nil {
+				done <- result{panicked: v, stack: debug.Stack()}
+			}
+		}()
+		value, err := proc(pool.WithContext(ctx), t)
+		done <- result{value: value, err: err}
+	}()
+	var r result
+	select {
+	case r = <-done:
+	case <-ctx.Done():
+		stats.detached.Add(1)
+		if !over.Swap(true) {
+			go func() {
+				if r := <-done; r.panicked != nil {
+					pool.log(slog.LevelError, "task panicked after timeout", itemKey(t), slog.String("panic", fmt.Sprint(r.panicked)), slog.String("stack", string(r.stack)))
+				}
+			}()
+			return timedOut(pool, t, timeout, fallback)
+		}
+		stats.detached.Add(-1) // the item is over meanwhile
+		r = <-done
+	}
+	if r.panicked != nil {
+		panic(r.panicked) // in the routine of the item, like proc
+	}
+	var later *Deferred
+	if errors.As(r.err, &later) {
+		return r.value, deferItem(later.Delay, later.Err, func(pool *Pools) (T, error) {
+			return withDeadline(pool, t, deadline, timeout, func(pool *Pools, _ T) (T, error) { return resumeAs[T](later, pool) }, fallback)
+		})
+	}
+	if ctx.Err() != nil {
+		return timedOut(pool, t, timeout, fallback)
+	}
+	return r.value, r.err
+}
+
+// timedOut returns the outcome of an item which exceeded its timeout.
+func timedOut[T any](pool *Pools, t T, timeout time.Duration, fallback func(T) T) (T, error) {
+	if err := pool.Context().Err(); err != nil {
+		return t, err // cancelled, not timed out
+	}
+	if fallback != nil {
+		return fallback(t), nil
+	}
+	return t, fmt.Errorf("%w after %v", ErrTimeout, timeout)
+}
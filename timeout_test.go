@@ -0,0 +1,133 @@
+package pipe_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+func TestWithTimeout(t *testing.T) {
+	// stuck blocks the odd items until their context is done
+	stuck := func(pool *pipe.Pools, i int) (int, error) {
+		if i%2 == 1 {
+			<-pool.Context().Done()
+		}
+		return i, nil
+	}
+
+	t.Run("timed_out", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2)
+		in := lo.SliceToChannel(0, []int{1, 2})
+
+		// Act
+		out, errc := pipe.PipeErr(pool, in, pipe.WithTimeout(20*time.Millisecond, stuck), nil)
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), []int{2})
+		err := <-errc
+		td.CmpErrorIs(t, err, pipe.ErrTimeout)
+		td.Cmp(t, err.Error(), "int item failed: item timed out after 20ms")
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 2)
+		in := lo.SliceToChannel(0, []int{1, 2})
+
+		// Act
+		out, errc := pipe.PipeErr(pool, in, pipe.WithTimeoutFallback(20*time.Millisecond, stuck, func(i int) int { return -i }), nil)
+
+		// Assert
+		td.Cmp(t, lo.ChannelToSlice(out), td.Bag(-1, 2))
+		td.CmpNoError(t, <-errc)
+	})
+
+	t.Run("cancel_childs", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1, 1)
+		var processed atomic.Int32
+		dispatcher, _ := pipe.NewDispatch(func(_ int, in chan<- int) {
+			for i := 0; i < 100; i++ {
+				in <- i
+			}
+		}, func(parent int, out <-chan int) int {
+			return parent + len(lo.ChannelToSlice(out))
+		})
+		child := func(_ *pipe.Pools, i int) (int, error) {
+			time.Sleep(5 * time.Millisecond)
+			processed.Add(1)
+			return i, nil
+		}
+
+		// Act
+		start := time.Now()
+		err := pipe.RunErr(pool, lo.SliceToChannel(0, []int{1}), pipe.WithTimeout(30*time.Millisecond, pipe.WrapErr(child, dispatcher, nil)), nil)
+
+		// Assert
+		td.CmpErrorIs(t, err, pipe.ErrTimeout)
+		td.Cmp(t, time.Since(start), td.Lt(250*time.Millisecond), "The item doesn't wait for its childs")
+		time.Sleep(20 * time.Millisecond) // let the submitted child end
+		td.Cmp(t, processed.Load(), td.Lt(int32(100)), "The remaining childs are not submitted")
+	})
+
+	t.Run("deaf_process", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1)
+		release := make(chan struct{})
+		deaf := func(_ *pipe.Pools, i int) (int, error) { // ignores its context
+			<-release
+			return i, nil
+		}
+
+		// Act
+		start := time.Now()
+		err := pipe.RunErr(pool, lo.SliceToChannel(0, []int{1}), pipe.WithTimeout(10*time.Millisecond, deaf), nil)
+
+		// Assert
+		td.CmpErrorIs(t, err, pipe.ErrTimeout)
+		td.Cmp(t, time.Since(start), td.Lt(200*time.Millisecond), "The item doesn't wait for its process")
+		td.Cmp(t, pool.Stats()[0].Detached, 1)
+		close(release)
+		td.Cmp(t, waitFor(func() bool { return pool.Stats()[0].Detached == 0 }), true)
+	})
+
+	t.Run("detached_bound", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1)
+		var running, maxRunning atomic.Int32
+		release := make(chan struct{})
+		deaf := func(_ *pipe.Pools, i int) (int, error) {
+			defer concurrent(&running, &maxRunning)()
+			<-release
+			return i, nil
+		}
+
+		// Act
+		err := pipe.RunErr(pool, lo.SliceToChannel(0, []int{1, 2, 3}), pipe.WithTimeout(10*time.Millisecond, deaf), nil)
+
+		// Assert
+		td.CmpErrorIs(t, err, pipe.ErrTimeout)
+		td.Cmp(t, err.Error(), td.Contains("1 timed out items of the depth still running"))
+		td.Cmp(t, maxRunning.Load(), int32(1), "A depth has at most as many detached items as workers")
+		close(release)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		// Arrange
+		pool := InitPool(t, 1)
+
+		// Act
+		err := pipe.RunErr(pool, lo.SliceToChannel(0, []int{1}), pipe.WithTimeout(time.Second, func(*pipe.Pools, int) (int, error) { panic("boom") }), nil)
+
+		// Assert
+		var panicErr *pipe.PanicError
+		td.Require(t).True(errors.As(err, &panicErr))
+		td.Cmp(t, panicErr.Value, "boom")
+	})
+}
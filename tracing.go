@@ -0,0 +1,159 @@
+package pipe
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// TraceID identifies a trace, like an OpenTelemetry trace id.
+type TraceID [16]byte
+
+// String returns the hex encoding of the id.
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID identifies a span, like an OpenTelemetry span id.
+type SpanID [8]byte
+
+// String returns the hex encoding of the id.
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// IsValid tells if the id is not zero.
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+// Span records the life of an item in the pool of a depth. The spans of the childs of an item, through Wrap, have the span of the item as parent.
+type Span struct {
+	TraceID   TraceID
+	SpanID    SpanID
+	ParentID  SpanID // zero for the root spans
+	Name      string // name of the stage, see Named
+	Depth     int
+	Submitted time.Time
+	Start     time.Time // the item waits for a worker between Submitted and Start
+	End       time.Time
+	Err       error // error of the item
+	Panic     any   // recovered value if the task panicked
+}
+
+// QueueWait returns the time spent by the item waiting for a worker.
+func (s Span) QueueWait() time.Duration {
+	return s.Start.Sub(s.Submitted)
+}
+
+// Duration returns the execution time of the item, including the time spent by its childs.
+func (s Span) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// SpanExporter receives the ended spans. It is called concurrently by the workers.
+type SpanExporter interface {
+	ExportSpan(Span)
+}
+
+// WithTracing enables the tracing of the items submitted to the pools: a Span is exported for each item at each depth.
+//
+// Spans are linked to their parent through the context of the pools, so a root span can be set with ContextWithSpan.
+func WithTracing(exporter SpanExporter) PoolsOption {
+	return func(c *poolsConfig) {
+		c.spanExporter = exporter
+	}
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a copy of ctx carrying the span, which will be the parent of the spans of the items processed with this context.
+func ContextWithSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span carried by ctx. The span of an item is available to its process through pool.Context().
+func SpanFromContext(ctx context.Context) (Span, bool) {
+	span, ok := ctx.Value(spanKey{}).(Span)
+	return span, ok
+}
+
+// startSpan starts the span of an item submitted to p, and binds it to the pools of its childs.
+func (p *Pools) startSpan(children *Pools, submitted time.Time) *Span {
+	if p == nil || p.config == nil || p.config.spanExporter == nil {
+		return nil
+	}
+	span := &Span{Name: p.name, Depth: p.depth, Submitted: submitted}
+	_, _ = rand.Read(span.SpanID[:])
+	if parent, ok := SpanFromContext(p.Context()); ok {
+		span.TraceID = parent.TraceID
+		span.ParentID = parent.SpanID
+	} else {
+		_, _ = rand.Read(span.TraceID[:])
+	}
+	children.ctx = ContextWithSpan(children.Context(), *span)
+	return span
+}
+
+// runInline runs the task of an item in the current routine, within the span of the item, so its childs are linked to it as well.
+func (p *Pools) runInline(children *Pools, f func(*Pools) error) {
+	span := p.startSpan(children, time.Now())
+	if span == nil {
+		_ = f(children)
+		return
+	}
+	ev := Event{Depth: p.depth, Name: p.name}
+	finished := false
+	defer func() {
+		ev.Elapsed = time.Since(span.Submitted)
+		if !finished {
+			ev.Panic = recover()
+			p.config.endSpan(span, ev)
+			panic(ev.Panic)
+		}
+		p.config.endSpan(span, ev)
+	}()
+	ev.Err = f(children)
+	finished = true
+}
+
+// endSpan exports the span of a task, from its last event.
+func (c *poolsConfig) endSpan(span *Span, ev Event) {
+	if span == nil {
+		return
+	}
+	span.Start = span.Submitted.Add(ev.Wait)
+	span.End = span.Start.Add(ev.Elapsed)
+	span.Err = ev.Err
+	span.Panic = ev.Panic
+	c.spanExporter.ExportSpan(*span)
+}
+
+// InMemoryExporter is a SpanExporter which keeps the spans in memory, for tests.
+type InMemoryExporter struct {
+	mutex sync.Mutex
+	spans []Span
+}
+
+// ExportSpan implements SpanExporter.
+func (e *InMemoryExporter) ExportSpan(span Span) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the exported spans, in their end order.
+func (e *InMemoryExporter) Spans() []Span {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]Span(nil), e.spans...)
+}
+
+// Reset removes the exported spans.
+func (e *InMemoryExporter) Reset() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.spans = nil
+}
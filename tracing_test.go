@@ -0,0 +1,116 @@
+package pipe_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+func TestTracing(t *testing.T) {
+	dispatcher, _ := pipe.NewDispatch(func(parent int, in chan<- int) {
+		for i := 0; i < parent; i++ {
+			in <- i
+		}
+	}, func(parent int, out <-chan int) int {
+		_ = lo.ChannelToSlice(out)
+		return parent
+	})
+	sleep := func(_ *pipe.Pools, i int) int {
+		time.Sleep(time.Millisecond)
+		return i
+	}
+
+	t.Run("child_spans_linked_to_parent", func(t *testing.T) {
+		// Arrange
+		var exporter pipe.InMemoryExporter
+		pool := InitPoolWithConfig(t, []int{1, 2}, pipe.WithTracing(&exporter))
+		in := lo.SliceToChannel(0, []int{2})
+
+		// Act
+		err := pipe.Run(pool.WithName("jobs"), in, pipe.Named("subjobs", pipe.Wrap(sleep, dispatcher)))
+
+		// Assert
+		td.CmpNoError(t, err)
+		spans := exporter.Spans()
+		td.Require(t).Len(spans, 3)
+		root, ok := lo.Find(spans, func(s pipe.Span) bool { return s.Depth == 0 })
+		td.Require(t).True(ok)
+		td.Cmp(t, root.Name, "jobs")
+		td.CmpFalse(t, root.ParentID.IsValid())
+		childs := lo.Filter(spans, func(s pipe.Span, _ int) bool { return s.Depth == 1 })
+		for _, child := range childs {
+			td.Cmp(t, child.Name, "subjobs")
+			td.Cmp(t, child.TraceID, root.TraceID)
+			td.Cmp(t, child.ParentID, root.SpanID)
+			td.Cmp(t, child.QueueWait(), td.Gte(time.Duration(0)))
+			td.Cmp(t, child.Duration(), td.Gte(time.Millisecond))
+			td.Cmp(t, root.Duration(), td.Gte(child.Duration()), "Parent span includes its childs")
+		}
+		td.CmpNot(t, childs[0].SpanID, childs[1].SpanID)
+	})
+
+	for name, sizes := range map[string][]int{"inline_parent": {0, 2}, "inline_childs": {0}} {
+		sizes := sizes
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			var exporter pipe.InMemoryExporter
+			pool := InitPoolWithConfig(t, sizes, pipe.WithTracing(&exporter))
+			in := lo.SliceToChannel(0, []int{2})
+
+			// Act
+			err := pipe.Run(pool, in, pipe.Wrap(sleep, dispatcher))
+
+			// Assert
+			td.CmpNoError(t, err)
+			spans := exporter.Spans()
+			td.Require(t).Len(spans, 3)
+			root, ok := lo.Find(spans, func(s pipe.Span) bool { return s.Depth == 0 })
+			td.Require(t).True(ok)
+			childs := lo.Filter(spans, func(s pipe.Span, _ int) bool { return s.Depth == 1 })
+			links := lo.Map(childs, func(s pipe.Span, _ int) any { return lo.T2(s.TraceID, s.ParentID) })
+			td.Cmp(t, links, []any{lo.T2(root.TraceID, root.SpanID), lo.T2(root.TraceID, root.SpanID)})
+			td.Cmp(t, root.Duration(), td.Gte(time.Millisecond), "The parent span includes its childs")
+		})
+	}
+
+	t.Run("span_in_process_context", func(t *testing.T) {
+		// Arrange
+		var exporter pipe.InMemoryExporter
+		pool := InitPoolWithConfig(t, []int{1}, pipe.WithTracing(&exporter))
+		root := pipe.Span{TraceID: pipe.TraceID{1}, SpanID: pipe.SpanID{2}}
+		var current pipe.Span
+
+		// Act
+		err := pipe.RunContext(pipe.ContextWithSpan(context.Background(), root), pool, lo.SliceToChannel(0, []int{1}), func(p *pipe.Pools, i int) int {
+			current, _ = pipe.SpanFromContext(p.Context())
+			return i
+		})
+
+		// Assert
+		td.CmpNoError(t, err)
+		spans := exporter.Spans()
+		td.Require(t).Len(spans, 1)
+		td.Cmp(t, spans[0].TraceID.String(), "01000000000000000000000000000000")
+		td.Cmp(t, spans[0].ParentID.String(), "0200000000000000")
+		td.Cmp(t, current.SpanID, spans[0].SpanID)
+
+		exporter.Reset()
+		td.CmpEmpty(t, exporter.Spans())
+	})
+
+	t.Run("error_span", func(t *testing.T) {
+		// Arrange
+		var exporter pipe.InMemoryExporter
+		pool := InitPoolWithConfig(t, []int{1}, pipe.WithTracing(&exporter))
+
+		// Act
+		_ = pipe.RunErr(pool, lo.SliceToChannel(0, []int{1}), failOdd, nil)
+
+		// Assert
+		td.Cmp(t, lo.Map(exporter.Spans(), func(s pipe.Span, _ int) error { return s.Err }), []error{errOdd})
+	})
+}
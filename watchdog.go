@@ -0,0 +1,169 @@
+package pipe
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// ErrStalled is wrapped by the StallError reported by the watchdog.
+var ErrStalled = errors.New("pipeline stalled")
+
+// StallError describes a stall detected by the watchdog: no task completed for a while, and some pools are saturated.
+type StallError struct {
+	Since  time.Duration // since the last task completion
+	Levels []LevelStats  // activity of every depth when the stall was detected
+	Stacks string        // stack traces of the workers running a task
+}
+
+// Error implements the error interface.
+func (e *StallError) Error() string {
+	full := lo.FilterMap(e.Levels, func(l LevelStats, _ int) (string, bool) {
+		return fmt.Sprintf("depth %d (%d/%d running, %d waiting)", l.Depth, l.Running, l.Capacity, l.Waiting), l.Saturated()
+	})
+	return fmt.Sprintf("%v: no task completed for %s, saturated pools: %s", ErrStalled, e.Since.Truncate(time.Millisecond), strings.Join(full, ", "))
+}
+
+// Unwrap returns ErrStalled.
+func (e *StallError) Unwrap() error {
+	return ErrStalled
+}
+
+// watchdog detects stalls of the pools.
+type watchdog struct {
+	period  time.Duration
+	onStall func(*StallError)
+	stop    chan struct{}
+	once    sync.Once
+	mutex   sync.Mutex
+	stalls  []error     // reported to the running Run functions when there is no callback
+	first   int         // number of the first kept stall
+	readers map[int]int // count of the running Run functions by number of the first stall they read
+}
+
+// WithWatchdog enables a watchdog on the pools: when no task completed for period while some pools are saturated, a *StallError is reported once per stall.
+//
+// The error is given to onStall, which may cancel the context of the pipeline for instance. If onStall is nil, the error is returned by the RunErr
+// (and the functions based on it) running when it is detected, and dropped otherwise.
+func WithWatchdog(period time.Duration, onStall func(*StallError)) PoolsOption {
+	return func(c *poolsConfig) {
+		c.watchdog = &watchdog{period: period, onStall: onStall, stop: make(chan struct{})}
+	}
+}
+
+// stallWatchdog returns the watchdog of the pools, nil if it is not enabled.
+func (p *Pools) stallWatchdog() *watchdog {
+	if p == nil || p.config == nil {
+		return nil
+	}
+	return p.config.watchdog
+}
+
+// start runs the watchdog of the pools until it is stopped.
+func (w *watchdog) start(p *Pools) {
+	if w == nil {
+		return
+	}
+	p.config.lastDone.Store(time.Now().UnixNano())
+	go func() {
+		ticker := time.NewTicker(max(w.period/4, time.Millisecond))
+		defer ticker.Stop()
+		reported := int64(0) // last completion of the reported stall
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+			}
+			lastDone := p.config.lastDone.Load()
+			since := time.Since(time.Unix(0, lastDone))
+			levels := p.Stats()
+			if since < w.period || lastDone == reported || !lo.SomeBy(levels, LevelStats.Saturated) {
+				continue
+			}
+			reported = lastDone
+			w.report(&StallError{Since: since, Levels: levels, Stacks: workerStacks()})
+		}
+	}()
+}
+
+// report hands a stall to the callback, or keeps it for the running Run functions.
+func (w *watchdog) report(err *StallError) {
+	if w.onStall != nil {
+		w.onStall(err)
+		return
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if len(w.readers) > 0 {
+		w.stalls = append(w.stalls, err)
+	}
+}
+
+// reported registers a running Run function, and returns the number of the next stall, to get the stalls which happen afterward with stallsSince.
+func (w *watchdog) reported() int {
+	if w == nil {
+		return 0
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	next := w.first + len(w.stalls)
+	if w.readers == nil {
+		w.readers = map[int]int{}
+	}
+	w.readers[next]++
+	return next
+}
+
+// stallsSince returns the stalls kept since the number returned by reported, and unregisters the Run function.
+// The stalls read by every running Run function are dropped.
+func (w *watchdog) stallsSince(next int) error {
+	if w == nil {
+		return nil
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	err := errors.Join(w.stalls[next-w.first:]...)
+	if w.readers[next]--; w.readers[next] == 0 {
+		delete(w.readers, next)
+	}
+	oldest := w.first + len(w.stalls)
+	for reader := range w.readers {
+		oldest = min(oldest, reader)
+	}
+	w.stalls = slices.Clone(w.stalls[oldest-w.first:])
+	w.first = oldest
+	return err
+}
+
+// close stops the watchdog.
+func (w *watchdog) close() {
+	if w == nil {
+		return
+	}
+	w.once.Do(func() { close(w.stop) })
+}
+
+// workerStacks returns the stack traces of the pool workers which run a pipe task.
+func workerStacks() string {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	stacks := lo.Filter(bytes.Split(buf, []byte("\n\n")), func(stack []byte, _ int) bool {
+		return bytes.Contains(stack, []byte("ants/v2.(*goWorker)")) && bytes.Contains(stack, []byte("fogfactory/pipe.(*Pools).submit"))
+	})
+	return string(bytes.Join(stacks, []byte("\n\n")))
+}
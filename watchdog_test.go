@@ -0,0 +1,90 @@
+package pipe_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fogfactory/pipe"
+	"github.com/maxatome/go-testdeep/td"
+	"github.com/samber/lo"
+)
+
+func TestWatchdog(t *testing.T) {
+	// stuck blocks the first item until release is closed
+	stuck := func(release <-chan bool) pipe.PoolProcess[int] {
+		return func(_ *pipe.Pools, i int) int {
+			if i == 0 {
+				<-release
+			}
+			return i
+		}
+	}
+
+	t.Run("callback", func(t *testing.T) {
+		// Arrange
+		release := make(chan bool)
+		var stall *pipe.StallError
+		pool := InitPoolWithConfig(t, []int{1}, pipe.WithWatchdog(20*time.Millisecond, func(err *pipe.StallError) {
+			stall = err
+			close(release)
+		}))
+		in := lo.SliceToChannel(0, lo.Range(2))
+
+		// Act
+		err := pipe.RunContext(pool.Context(), pool, in, stuck(release))
+
+		// Assert
+		td.CmpNoError(t, err)
+		td.Require(t).NotNil(stall)
+		td.CmpErrorIs(t, stall, pipe.ErrStalled)
+		td.Cmp(t, stall.Since, td.Gte(20*time.Millisecond))
+		td.Cmp(t, stall.Levels, []pipe.LevelStats{{Depth: 0, Capacity: 1, Running: 1, Waiting: 1}})
+		td.CmpContains(t, stall.Stacks, "TestWatchdog")
+		td.CmpContains(t, stall.Error(), "saturated pools: depth 0 (1/1 running, 1 waiting)")
+	})
+
+	t.Run("returned_by_run", func(t *testing.T) {
+		// Arrange
+		release := make(chan bool)
+		pool := InitPoolWithConfig(t, []int{1}, pipe.WithWatchdog(20*time.Millisecond, nil))
+		in := lo.SliceToChannel(0, lo.Range(2))
+		time.AfterFunc(100*time.Millisecond, func() { close(release) })
+
+		// Act
+		err := pipe.RunErr(pool, in, pipe.AsErrProcess(stuck(release)), nil)
+
+		// Assert
+		td.CmpErrorIs(t, err, pipe.ErrStalled)
+	})
+
+	t.Run("dropped_once_read", func(t *testing.T) {
+		// Arrange
+		release := make(chan bool)
+		pool := InitPoolWithConfig(t, []int{1}, pipe.WithWatchdog(10*time.Millisecond, nil))
+		out := pipe.Pipe(pool, lo.SliceToChannel(0, lo.Range(2)), stuck(release)) // stalls without any Run
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		lo.ChannelToSlice(out)
+		release = make(chan bool)
+		time.AfterFunc(50*time.Millisecond, func() { close(release) })
+
+		// Act
+		err := pipe.RunErr(pool, lo.SliceToChannel(0, lo.Range(2)), pipe.AsErrProcess(stuck(release)), nil)
+
+		// Assert
+		td.CmpErrorIs(t, err, pipe.ErrStalled)
+		td.Cmp(t, pool.KeptStalls(), 0, "The stalls are dropped once read by every running Run")
+	})
+
+	t.Run("no_stall_when_idle", func(t *testing.T) {
+		// Arrange
+		pool := InitPoolWithConfig(t, []int{1}, pipe.WithWatchdog(5*time.Millisecond, nil))
+		time.Sleep(20 * time.Millisecond)
+
+		// Act
+		err := pipe.RunErr(pool, lo.SliceToChannel(0, lo.Range(2)), pipe.AsErrProcess(identity[int]), nil)
+
+		// Assert
+		td.CmpNoError(t, err)
+	})
+}